@@ -11,8 +11,10 @@ import (
 	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/v2/destination"
 	"github.com/bitrise-io/go-xcode/v2/xcodeversion"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/simulator"
 	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/step"
 	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
 )
 
 func main() {
@@ -32,13 +34,13 @@ func run() int {
 		return exitCode
 	}
 
-	result, err := xcodebuildTester.Run(*config)
+	results, err := xcodebuildTester.RunAll(*config)
 	if err != nil {
 		logger.TErrorf(err.Error())
 		exitCode = 1
 	}
 
-	if err = xcodebuildTester.ExportOutputs(*result); err != nil {
+	if err = xcodebuildTester.ExportOutputs(*config, results); err != nil {
 		logger.Errorf(err.Error())
 		exitCode = 1
 	}
@@ -60,7 +62,10 @@ func createXcodebuildTester(logger log.Logger) step.XcodebuildTester {
 	}
 	deviceFinder := destination.NewDeviceFinder(logger, commandFactory, xcodeVersion)
 	xcbuild := xcodebuild.New(logger, commandFactory, pathProvider, pathChecker)
+	xcresultReader := xcresult.NewReader(commandFactory)
+	xcresultMerger := xcresult.NewMerger(commandFactory)
+	simulatorManager := simulator.NewManager(logger, commandFactory)
 	outputExporter := step.NewOutputExporter()
 
-	return step.NewXcodebuildTester(logger, inputParser, deviceFinder, pathChecker, xcbuild, outputEnvStore, outputExporter)
+	return step.NewXcodebuildTester(logger, inputParser, deviceFinder, simulatorManager, pathProvider, pathChecker, commandFactory, xcbuild, xcresultReader, xcresultMerger, outputEnvStore, outputExporter)
 }
@@ -23,6 +23,20 @@ func (_m *OutputExporter) CopyAndSaveTestData(sourceTestOutputDir string, target
 	return r0
 }
 
+// CopyFile provides a mock function with given fields: src, dst
+func (_m *OutputExporter) CopyFile(src string, dst string) error {
+	ret := _m.Called(src, dst)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(src, dst)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ZipAndExportOutput provides a mock function with given fields: artifact, destinationZipPth, envKey
 func (_m *OutputExporter) ZipAndExportOutput(artifact string, destinationZipPth string, envKey string) error {
 	ret := _m.Called(artifact, destinationZipPth, envKey)
@@ -0,0 +1,41 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Runtime is a simulator runtime listed by `xcrun simctl list runtimes -j`.
+type Runtime struct {
+	Identifier  string `json:"identifier"`
+	Platform    string `json:"platform"`
+	Version     string `json:"version"`
+	Name        string `json:"name"`
+	IsAvailable bool   `json:"isAvailable"`
+}
+
+// ListRuntimes lists the installed simulator runtimes.
+func (m manager) ListRuntimes() ([]Runtime, error) {
+	out, err := m.simctl("list", "runtimes", "-j")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list simulator runtimes: %w", err)
+	}
+
+	var list struct {
+		Runtimes []Runtime `json:"runtimes"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse simulator runtime list: %w", err)
+	}
+	return list.Runtimes, nil
+}
+
+// IsPlatform returns whether the runtime is of the given platform (for example iOS).
+func (r Runtime) IsPlatform(platform string) bool {
+	if r.Platform != "" {
+		return r.Platform == platform
+	}
+	// Runtimes listed by Xcode 11 and earlier have no platform field.
+	return strings.HasPrefix(r.Name, platform)
+}
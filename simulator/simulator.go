@@ -0,0 +1,99 @@
+package simulator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/destination"
+)
+
+const stateBooted = "Booted"
+
+// Manager manages simulator devices with `xcrun simctl`.
+type Manager interface {
+	Clone(device destination.Device, name string) (destination.Device, error)
+	Delete(device destination.Device) error
+	Reset(device destination.Device) error
+	ListRuntimes() ([]Runtime, error)
+}
+
+type manager struct {
+	logger         log.Logger
+	commandFactory command.Factory
+}
+
+func NewManager(logger log.Logger, commandFactory command.Factory) Manager {
+	return manager{
+		logger:         logger,
+		commandFactory: commandFactory,
+	}
+}
+
+// Clone creates a new simulator device with the same device type, runtime and content as the given device.
+// A booted device is shut down first, as simctl can only clone shut down devices, and it is booted again after cloning.
+func (m manager) Clone(device destination.Device, name string) (destination.Device, error) {
+	if device.Status == stateBooted {
+		if _, err := m.simctl("shutdown", device.ID); err != nil {
+			return destination.Device{}, fmt.Errorf("failed to shut down simulator (%s): %w", device.ID, err)
+		}
+		defer func() {
+			if _, err := m.simctl("boot", device.ID); err != nil {
+				m.logger.Warnf("Failed to boot simulator (%s) again after cloning it: %s", device.ID, err)
+			}
+		}()
+	}
+
+	out, err := m.simctl("clone", device.ID, name)
+	if err != nil {
+		return destination.Device{}, fmt.Errorf("failed to clone simulator (%s): %w", device.ID, err)
+	}
+
+	clone := device
+	clone.ID = strings.TrimSpace(out)
+	clone.Name = name
+	clone.Status = "Shutdown"
+	return clone, nil
+}
+
+// Delete deletes the simulator device.
+func (m manager) Delete(device destination.Device) error {
+	if _, err := m.simctl("delete", device.ID); err != nil {
+		return fmt.Errorf("failed to delete simulator (%s): %w", device.ID, err)
+	}
+	return nil
+}
+
+// Reset erases the content and settings of the simulator device and boots it again, it returns once the device finished booting.
+// The device is shut down first, as simctl can only erase shut down devices.
+func (m manager) Reset(device destination.Device) error {
+	if _, err := m.simctl("shutdown", device.ID); err != nil {
+		// Shutting down an already shut down device fails, the erase below reports the real problems.
+		m.logger.Warnf("Failed to shut down simulator (%s): %s", device.ID, err)
+	}
+
+	if _, err := m.simctl("erase", device.ID); err != nil {
+		return fmt.Errorf("failed to erase simulator (%s): %w", device.ID, err)
+	}
+
+	if _, err := m.simctl("boot", device.ID); err != nil {
+		return fmt.Errorf("failed to boot simulator (%s): %w", device.ID, err)
+	}
+
+	if _, err := m.simctl("bootstatus", device.ID); err != nil {
+		return fmt.Errorf("failed to wait for simulator (%s) to boot: %w", device.ID, err)
+	}
+	return nil
+}
+
+func (m manager) simctl(args ...string) (string, error) {
+	cmd := m.commandFactory.Create("xcrun", append([]string{"simctl"}, args...), nil)
+	m.logger.TDonef("$ %s", cmd.PrintableCommandArgs())
+
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w, output: %s", err, out)
+	}
+	return out, nil
+}
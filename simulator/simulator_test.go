@@ -0,0 +1,136 @@
+package simulator_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/simulator"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	shutdownCommand := new(mocks.Command)
+	shutdownCommand.On("PrintableCommandArgs").Return("")
+	shutdownCommand.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	bootCommand := new(mocks.Command)
+	bootCommand.On("PrintableCommandArgs").Return("")
+	bootCommand.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	cloneCommand := new(mocks.Command)
+	cloneCommand.On("PrintableCommandArgs").Return("")
+	cloneCommand.On("RunAndReturnTrimmedCombinedOutput").Return("D64FA78C-5A25-4BF3-9EE8-855761042DEE", nil)
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcrun", []string{"simctl", "shutdown", "test-UDID"}, mock.Anything).Return(shutdownCommand).Once()
+	factoryMock.On("Create", "xcrun", []string{"simctl", "clone", "test-UDID", "iPhone 15 - Clone 1"}, mock.Anything).Return(cloneCommand).Once()
+	factoryMock.On("Create", "xcrun", []string{"simctl", "boot", "test-UDID"}, mock.Anything).Return(bootCommand).Once()
+
+	manager := simulator.NewManager(log.NewLogger(), factoryMock)
+
+	clone, err := manager.Clone(destination.Device{
+		ID:       "test-UDID",
+		Status:   "Booted",
+		Platform: "iOS Simulator",
+		Name:     "iPhone 15",
+		OS:       "17.5",
+	}, "iPhone 15 - Clone 1")
+	require.NoError(t, err)
+	require.Equal(t, destination.Device{
+		ID:       "D64FA78C-5A25-4BF3-9EE8-855761042DEE",
+		Status:   "Shutdown",
+		Platform: "iOS Simulator",
+		Name:     "iPhone 15 - Clone 1",
+		OS:       "17.5",
+	}, clone)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	deleteCommand := new(mocks.Command)
+	deleteCommand.On("PrintableCommandArgs").Return("")
+	deleteCommand.On("RunAndReturnTrimmedCombinedOutput").Return("Invalid device: test-UDID", errors.New("exit status 1"))
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcrun", []string{"simctl", "delete", "test-UDID"}, mock.Anything).Return(deleteCommand).Once()
+
+	manager := simulator.NewManager(log.NewLogger(), factoryMock)
+
+	err := manager.Delete(destination.Device{ID: "test-UDID"})
+	require.EqualError(t, err, "failed to delete simulator (test-UDID): exit status 1, output: Invalid device: test-UDID")
+}
+
+func TestReset(t *testing.T) {
+	shutdownCommand := new(mocks.Command)
+	shutdownCommand.On("PrintableCommandArgs").Return("")
+	shutdownCommand.On("RunAndReturnTrimmedCombinedOutput").Return("Unable to shutdown device in current state: Shutdown", errors.New("exit status 149"))
+
+	succeedingCommand := new(mocks.Command)
+	succeedingCommand.On("PrintableCommandArgs").Return("")
+	succeedingCommand.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcrun", []string{"simctl", "shutdown", "test-UDID"}, mock.Anything).Return(shutdownCommand).Once()
+	factoryMock.On("Create", "xcrun", []string{"simctl", "erase", "test-UDID"}, mock.Anything).Return(succeedingCommand).Once()
+	factoryMock.On("Create", "xcrun", []string{"simctl", "boot", "test-UDID"}, mock.Anything).Return(succeedingCommand).Once()
+	factoryMock.On("Create", "xcrun", []string{"simctl", "bootstatus", "test-UDID"}, mock.Anything).Return(succeedingCommand).Once()
+
+	manager := simulator.NewManager(log.NewLogger(), factoryMock)
+
+	err := manager.Reset(destination.Device{ID: "test-UDID"})
+	require.NoError(t, err)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestReset_EraseFails(t *testing.T) {
+	shutdownCommand := new(mocks.Command)
+	shutdownCommand.On("PrintableCommandArgs").Return("")
+	shutdownCommand.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	eraseCommand := new(mocks.Command)
+	eraseCommand.On("PrintableCommandArgs").Return("")
+	eraseCommand.On("RunAndReturnTrimmedCombinedOutput").Return("Unable to erase contents and settings in current state: Booted", errors.New("exit status 149"))
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcrun", []string{"simctl", "shutdown", "test-UDID"}, mock.Anything).Return(shutdownCommand).Once()
+	factoryMock.On("Create", "xcrun", []string{"simctl", "erase", "test-UDID"}, mock.Anything).Return(eraseCommand).Once()
+
+	manager := simulator.NewManager(log.NewLogger(), factoryMock)
+
+	err := manager.Reset(destination.Device{ID: "test-UDID"})
+	require.EqualError(t, err, "failed to erase simulator (test-UDID): exit status 149, output: Unable to erase contents and settings in current state: Booted")
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestListRuntimes(t *testing.T) {
+	listCommand := new(mocks.Command)
+	listCommand.On("PrintableCommandArgs").Return("")
+	listCommand.On("RunAndReturnTrimmedCombinedOutput").Return(`{
+  "runtimes" : [
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.iOS-17-5", "platform" : "iOS", "version" : "17.5", "name" : "iOS 17.5", "isAvailable" : true},
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.iOS-13-0", "version" : "13.0", "name" : "iOS 13.0", "isAvailable" : false}
+  ]
+}`, nil)
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcrun", []string{"simctl", "list", "runtimes", "-j"}, mock.Anything).Return(listCommand).Once()
+
+	manager := simulator.NewManager(log.NewLogger(), factoryMock)
+
+	runtimes, err := manager.ListRuntimes()
+	require.NoError(t, err)
+	require.Equal(t, []simulator.Runtime{
+		{Identifier: "com.apple.CoreSimulator.SimRuntime.iOS-17-5", Platform: "iOS", Version: "17.5", Name: "iOS 17.5", IsAvailable: true},
+		{Identifier: "com.apple.CoreSimulator.SimRuntime.iOS-13-0", Version: "13.0", Name: "iOS 13.0"},
+	}, runtimes)
+	require.True(t, runtimes[1].IsPlatform("iOS"), "runtimes without platform are matched by their name")
+
+	factoryMock.AssertExpectations(t)
+}
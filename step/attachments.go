@@ -0,0 +1,142 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	exportAttachmentsNone         = "none"
+	exportAttachmentsFailuresOnly = "failures_only"
+	exportAttachmentsAll          = "all"
+
+	attachmentsDirName          = "xcresult_attachments"
+	attachmentsManifestFileName = "manifest.json"
+)
+
+// ExportedAttachment is an entry of the attachments manifest.
+// Path is relative to the attachments dir: ResultBundle/TestTarget/TestClass/testMethod/attempt-N/name.
+type ExportedAttachment struct {
+	TestIdentifier        string `json:"test_identifier"`
+	XcresultPath          string `json:"xcresult_path"`
+	Attempt               int    `json:"attempt"`
+	Name                  string `json:"name"`
+	Path                  string `json:"path"`
+	Device                string `json:"device,omitempty"`
+	AssociatedWithFailure bool   `json:"associated_with_failure"`
+}
+
+// isExportingAttachments returns whether the test attachments are extracted to the deploy dir.
+func isExportingAttachments(config Config) bool {
+	return config.DeployDir != "" && config.ExportAttachments != "" && config.ExportAttachments != exportAttachmentsNone
+}
+
+// exportAttachments extracts the attachments of each test result bundle into the attachments dir of the deploy dir,
+// along with a manifest of the extracted attachments.
+func (s XcodebuildTester) exportAttachments(config Config, results []Result) {
+	dir := filepath.Join(config.DeployDir, attachmentsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		s.logger.Warnf("Failed to create the attachments dir: %s", err)
+		return
+	}
+
+	var (
+		manifest  = []ExportedAttachment{}
+		extracted bool
+	)
+	for _, result := range results {
+		if result.TestOutputDir == "" {
+			continue
+		}
+
+		attachments, err := s.extractAttachments(result.TestOutputDir, dir, config.ExportAttachments == exportAttachmentsFailuresOnly)
+		if err != nil {
+			s.logger.Warnf("Failed to extract the attachments of %s: %s", result.TestOutputDir, err)
+			continue
+		}
+		manifest = append(manifest, attachments...)
+		extracted = true
+	}
+	if !extracted {
+		return
+	}
+
+	if err := writeJSON(filepath.Join(dir, attachmentsManifestFileName), manifest); err != nil {
+		s.logger.Warnf("Failed to write the attachments manifest: %s", err)
+		return
+	}
+	s.exportOutput(attachmentsDirKey, dir)
+}
+
+// extractAttachments exports the attachments of the result bundle into a staging dir next to their final place,
+// then moves them into the folder of their test and attempt.
+func (s XcodebuildTester) extractAttachments(xcresultPth, dir string, onlyFailures bool) ([]ExportedAttachment, error) {
+	bundleName := strings.TrimSuffix(filepath.Base(xcresultPth), filepath.Ext(xcresultPth))
+
+	stagingDir, err := os.MkdirTemp(dir, "."+bundleName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(stagingDir); err != nil {
+			s.logger.Warnf("Failed to remove the attachments staging dir: %s", err)
+		}
+	}()
+
+	tests, err := s.xcresultReader.ExportAttachments(xcresultPth, stagingDir, onlyFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	var exported []ExportedAttachment
+	for _, test := range tests {
+		for _, attachment := range test.Attachments {
+			attempt := attachment.RepetitionNumber
+			if attempt < 1 {
+				attempt = 1
+			}
+
+			testDir := filepath.Join(bundleName, pathComponent(test.TestTarget()), pathComponent(test.TestClass()), pathComponent(test.TestMethod()), fmt.Sprintf("attempt-%d", attempt))
+			if err := os.MkdirAll(filepath.Join(dir, testDir), 0755); err != nil {
+				return nil, err
+			}
+
+			name := attachment.SuggestedHumanReadableName
+			if name == "" {
+				name = attachment.ExportedFileName
+			}
+			pth := filepath.Join(testDir, pathComponent(name))
+			if _, err := os.Stat(filepath.Join(dir, pth)); err == nil {
+				// The exported file names are unique.
+				pth = filepath.Join(testDir, attachment.ExportedFileName)
+			}
+
+			if err := os.Rename(filepath.Join(stagingDir, attachment.ExportedFileName), filepath.Join(dir, pth)); err != nil {
+				return nil, err
+			}
+
+			exported = append(exported, ExportedAttachment{
+				TestIdentifier:        test.TestTarget() + "/" + test.TestClass() + "/" + test.TestMethod(),
+				XcresultPath:          xcresultPth,
+				Attempt:               attempt,
+				Name:                  name,
+				Path:                  pth,
+				Device:                attachment.DeviceName,
+				AssociatedWithFailure: attachment.IsAssociatedWithFailure,
+			})
+		}
+	}
+
+	return exported, nil
+}
+
+// pathComponent makes the name usable as a single path component.
+func pathComponent(name string) string {
+	name = strings.ReplaceAll(name, string(filepath.Separator), "_")
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,96 @@
+package step
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenFailureAttachments_WhenStepExportsOutputs_ThenAttachmentsExtractedByTestAndAttempt(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	expectTestSummaryAnnotation(testingMocks.commandFactory, mock.Anything)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-App.xcresult").Return(&xcresult.TestResults{}, nil)
+	testingMocks.xcresultReader.On("ExportAttachments", "/tmp/Test-App.xcresult", mock.Anything, true).Run(func(args mock.Arguments) {
+		outputDir := args.String(1)
+		require.NoError(t, os.WriteFile(filepath.Join(outputDir, "0A1B.png"), []byte("first"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(outputDir, "1B2C.png"), []byte("second"), 0644))
+	}).Return([]xcresult.TestAttachments{{
+		TestIdentifier:    "LoginTests/testLogin()",
+		TestIdentifierURL: "test://com.apple.xcode/App/AppUITests/LoginTests/testLogin",
+		Attachments: []xcresult.Attachment{
+			{ExportedFileName: "0A1B.png", SuggestedHumanReadableName: "Screenshot.png", IsAssociatedWithFailure: true, DeviceName: "iPhone 15", RepetitionNumber: 1},
+			{ExportedFileName: "1B2C.png", SuggestedHumanReadableName: "Screenshot.png", IsAssociatedWithFailure: true, DeviceName: "iPhone 15", RepetitionNumber: 2},
+		},
+	}}, nil)
+
+	// When
+	err := step.ExportOutputs(Config{DeployDir: deployDir, ExportAttachments: exportAttachmentsFailuresOnly}, []Result{{TestOutputDir: "/tmp/Test-App.xcresult", DeployDir: deployDir}})
+
+	// Then
+	require.NoError(t, err)
+
+	dir := filepath.Join(deployDir, "xcresult_attachments")
+	testingMocks.envRepository.AssertCalled(t, "Set", attachmentsDirKey, dir)
+
+	content, err := os.ReadFile(filepath.Join(dir, "Test-App", "AppUITests", "LoginTests", "testLogin", "attempt-2", "Screenshot.png"))
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "manifest.json"))
+	require.NoError(t, err)
+	var manifest []ExportedAttachment
+	require.NoError(t, json.Unmarshal(content, &manifest))
+	require.Equal(t, []ExportedAttachment{
+		{
+			TestIdentifier:        "AppUITests/LoginTests/testLogin",
+			XcresultPath:          "/tmp/Test-App.xcresult",
+			Attempt:               1,
+			Name:                  "Screenshot.png",
+			Path:                  filepath.Join("Test-App", "AppUITests", "LoginTests", "testLogin", "attempt-1", "Screenshot.png"),
+			Device:                "iPhone 15",
+			AssociatedWithFailure: true,
+		},
+		{
+			TestIdentifier:        "AppUITests/LoginTests/testLogin",
+			XcresultPath:          "/tmp/Test-App.xcresult",
+			Attempt:               2,
+			Name:                  "Screenshot.png",
+			Path:                  filepath.Join("Test-App", "AppUITests", "LoginTests", "testLogin", "attempt-2", "Screenshot.png"),
+			Device:                "iPhone 15",
+			AssociatedWithFailure: true,
+		},
+	}, manifest)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "the staging dir is removed")
+}
+
+func Test_GivenAttachmentsNotExported_WhenStepExportsOutputs_ThenAttachmentsNotExtracted(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	expectTestSummaryAnnotation(testingMocks.commandFactory, mock.Anything)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-App.xcresult").Return(&xcresult.TestResults{}, nil)
+
+	// When
+	err := step.ExportOutputs(Config{DeployDir: deployDir, ExportAttachments: exportAttachmentsNone}, []Result{{TestOutputDir: "/tmp/Test-App.xcresult", DeployDir: deployDir}})
+
+	// Then
+	require.NoError(t, err)
+	testingMocks.xcresultReader.AssertNotCalled(t, "ExportAttachments", mock.Anything, mock.Anything, mock.Anything)
+	require.NoDirExists(t, filepath.Join(deployDir, "xcresult_attachments"))
+}
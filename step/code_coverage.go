@@ -0,0 +1,310 @@
+package step
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+const (
+	codeCoverageLcovFileName      = "coverage.lcov"
+	codeCoverageCoberturaFileName = "cobertura.xml"
+	codeCoverageSummaryFileName   = "coverage_summary.json"
+)
+
+// CodeCoverage is the line coverage of the tests, combined from the coverage of all the test result bundles:
+// a line is covered if it was executed in any of the test runs. The coverage percentages are in the 0-100 range.
+type CodeCoverage struct {
+	LineCoverage    float64              `json:"line_coverage"`
+	CoveredLines    int                  `json:"covered_lines"`
+	ExecutableLines int                  `json:"executable_lines"`
+	Targets         []CodeCoverageTarget `json:"targets"`
+}
+
+// CodeCoverageTarget is the line coverage of a build product (like App.app or Kit.framework).
+type CodeCoverageTarget struct {
+	Name            string             `json:"name"`
+	LineCoverage    float64            `json:"line_coverage"`
+	CoveredLines    int                `json:"covered_lines"`
+	ExecutableLines int                `json:"executable_lines"`
+	Files           []CodeCoverageFile `json:"files"`
+}
+
+type CodeCoverageFile struct {
+	Path            string  `json:"path"`
+	LineCoverage    float64 `json:"line_coverage"`
+	CoveredLines    int     `json:"covered_lines"`
+	ExecutableLines int     `json:"executable_lines"`
+
+	// lines are the executable lines of the file, sorted by their line numbers.
+	lines []xcresult.LineCoverage
+}
+
+// combineCodeCoverage combines the coverage reports and the line coverages of the test result bundles,
+// the execution counts of the same lines are summed.
+func combineCodeCoverage(reports []*xcresult.CoverageReport, lineCoverages []map[string][]xcresult.LineCoverage) CodeCoverage {
+	executionCounts := map[string]map[int]int{}
+	for _, lineCoverage := range lineCoverages {
+		for pth, lines := range lineCoverage {
+			if executionCounts[pth] == nil {
+				executionCounts[pth] = map[int]int{}
+			}
+			for _, line := range lines {
+				if line.IsExecutable {
+					executionCounts[pth][line.Line] += line.ExecutionCount
+				}
+			}
+		}
+	}
+
+	var (
+		targetNames []string
+		targetFiles = map[string][]string{}
+		isKnownFile = map[string]bool{}
+	)
+	for _, report := range reports {
+		for _, target := range report.Targets {
+			if _, ok := targetFiles[target.Name]; !ok {
+				targetNames = append(targetNames, target.Name)
+				targetFiles[target.Name] = nil
+			}
+			for _, file := range target.Files {
+				key := target.Name + "\n" + file.Path
+				if !isKnownFile[key] {
+					isKnownFile[key] = true
+					targetFiles[target.Name] = append(targetFiles[target.Name], file.Path)
+				}
+			}
+		}
+	}
+
+	var coverage CodeCoverage
+	for _, name := range targetNames {
+		target := CodeCoverageTarget{Name: name}
+		for _, pth := range targetFiles[name] {
+			file := CodeCoverageFile{Path: pth}
+			for line, count := range executionCounts[pth] {
+				file.lines = append(file.lines, xcresult.LineCoverage{Line: line, IsExecutable: true, ExecutionCount: count})
+				if count > 0 {
+					file.CoveredLines++
+				}
+			}
+			sort.Slice(file.lines, func(i, j int) bool { return file.lines[i].Line < file.lines[j].Line })
+			file.ExecutableLines = len(file.lines)
+			file.LineCoverage = coveragePercentage(file.CoveredLines, file.ExecutableLines)
+
+			target.CoveredLines += file.CoveredLines
+			target.ExecutableLines += file.ExecutableLines
+			target.Files = append(target.Files, file)
+		}
+		target.LineCoverage = coveragePercentage(target.CoveredLines, target.ExecutableLines)
+
+		coverage.CoveredLines += target.CoveredLines
+		coverage.ExecutableLines += target.ExecutableLines
+		coverage.Targets = append(coverage.Targets, target)
+	}
+	coverage.LineCoverage = coveragePercentage(coverage.CoveredLines, coverage.ExecutableLines)
+
+	return coverage
+}
+
+// coveragePercentage returns the percentage of the covered lines, rounded to two decimals.
+func coveragePercentage(covered, executable int) float64 {
+	if executable == 0 {
+		return 0
+	}
+	return math.Round(float64(covered)/float64(executable)*10000) / 100
+}
+
+// createLcovReport converts the code coverage to the lcov tracefile format, a source file is listed once
+// even if it is part of multiple targets.
+func createLcovReport(coverage CodeCoverage) string {
+	var b strings.Builder
+	isListed := map[string]bool{}
+	for _, target := range coverage.Targets {
+		for _, file := range target.Files {
+			if isListed[file.Path] {
+				continue
+			}
+			isListed[file.Path] = true
+
+			b.WriteString("TN:\n")
+			b.WriteString("SF:" + file.Path + "\n")
+			for _, line := range file.lines {
+				b.WriteString(fmt.Sprintf("DA:%d,%d\n", line.Line, line.ExecutionCount))
+			}
+			b.WriteString(fmt.Sprintf("LF:%d\n", file.ExecutableLines))
+			b.WriteString(fmt.Sprintf("LH:%d\n", file.CoveredLines))
+			b.WriteString("end_of_record\n")
+		}
+	}
+	return b.String()
+}
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      string             `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+// coberturaPackage is a target of the code coverage, with one class per source file.
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity string          `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// createCoberturaReport converts the code coverage to a Cobertura report, branch coverage is not collected by xccov.
+func createCoberturaReport(coverage CodeCoverage, timestamp time.Time) coberturaCoverage {
+	report := coberturaCoverage{
+		LineRate:     coberturaRate(coverage.LineCoverage),
+		BranchRate:   "0",
+		LinesCovered: coverage.CoveredLines,
+		LinesValid:   coverage.ExecutableLines,
+		Complexity:   "0",
+		Version:      "1.9",
+		Timestamp:    timestamp.Unix(),
+	}
+
+	for _, target := range coverage.Targets {
+		pkg := coberturaPackage{
+			Name:       target.Name,
+			LineRate:   coberturaRate(target.LineCoverage),
+			BranchRate: "0",
+			Complexity: "0",
+		}
+		for _, file := range target.Files {
+			class := coberturaClass{
+				Name:       strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path)),
+				Filename:   file.Path,
+				LineRate:   coberturaRate(file.LineCoverage),
+				BranchRate: "0",
+				Complexity: "0",
+			}
+			for _, line := range file.lines {
+				class.Lines = append(class.Lines, coberturaLine{Number: line.Line, Hits: line.ExecutionCount})
+			}
+			pkg.Classes = append(pkg.Classes, class)
+		}
+		report.Packages = append(report.Packages, pkg)
+	}
+
+	return report
+}
+
+// coberturaRate converts the coverage percentage to the 0-1 rate of the Cobertura report.
+func coberturaRate(percentage float64) string {
+	return strconv.FormatFloat(percentage/100, 'f', 4, 64)
+}
+
+// exportCodeCoverage reads the code coverage of the test result bundles, exports the line coverage percentages
+// and writes the lcov, Cobertura and JSON summary reports to the deploy dir.
+func (s XcodebuildTester) exportCodeCoverage(config Config, results []Result) {
+	var (
+		reports       []*xcresult.CoverageReport
+		lineCoverages []map[string][]xcresult.LineCoverage
+	)
+	for _, result := range results {
+		if result.TestOutputDir == "" {
+			continue
+		}
+
+		report, err := s.xcresultReader.ReadCoverageReport(result.TestOutputDir)
+		if err != nil {
+			s.logger.Warnf("Failed to read the code coverage of %s: %s", result.TestOutputDir, err)
+			continue
+		}
+		lineCoverage, err := s.xcresultReader.ReadLineCoverage(result.TestOutputDir)
+		if err != nil {
+			s.logger.Warnf("Failed to read the code coverage of %s: %s", result.TestOutputDir, err)
+			continue
+		}
+
+		reports = append(reports, report)
+		lineCoverages = append(lineCoverages, lineCoverage)
+	}
+	if len(reports) == 0 {
+		return
+	}
+
+	coverage := combineCodeCoverage(reports, lineCoverages)
+
+	s.exportOutput(codeCoverageKey, fmt.Sprintf("%.2f", coverage.LineCoverage))
+
+	targetCoverages := map[string]float64{}
+	for _, target := range coverage.Targets {
+		targetCoverages[target.Name] = target.LineCoverage
+	}
+	if content, err := json.Marshal(targetCoverages); err != nil {
+		s.logger.Warnf("Failed to export: %s: %s", targetCodeCoverageKey, err)
+	} else {
+		s.exportOutput(targetCodeCoverageKey, string(content))
+	}
+
+	if config.DeployDir == "" {
+		return
+	}
+
+	pth := filepath.Join(config.DeployDir, codeCoverageLcovFileName)
+	if err := os.WriteFile(pth, []byte(createLcovReport(coverage)), 0644); err != nil {
+		s.logger.Warnf("Failed to write the lcov code coverage report: %s", err)
+	} else {
+		s.exportOutput(codeCoverageLcovKey, pth)
+	}
+
+	pth = filepath.Join(config.DeployDir, codeCoverageCoberturaFileName)
+	if err := writeCoberturaReport(pth, createCoberturaReport(coverage, time.Now())); err != nil {
+		s.logger.Warnf("Failed to write the Cobertura code coverage report: %s", err)
+	} else {
+		s.exportOutput(codeCoverageCoberturaKey, pth)
+	}
+
+	pth = filepath.Join(config.DeployDir, codeCoverageSummaryFileName)
+	if err := writeJSON(pth, coverage); err != nil {
+		s.logger.Warnf("Failed to write the code coverage summary: %s", err)
+	} else {
+		s.exportOutput(codeCoverageSummaryKey, pth)
+	}
+}
+
+func writeCoberturaReport(pth string, report coberturaCoverage) error {
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pth, append([]byte(xml.Header), content...), 0644)
+}
@@ -0,0 +1,144 @@
+package step
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	gameSwiftPath    = "/src/BullsEye/BullsEyeGame.swift"
+	contentSwiftPath = "/src/BullsEye/ContentView.swift"
+)
+
+func coverageReportOf(files ...string) *xcresult.CoverageReport {
+	target := xcresult.TargetCoverage{Name: "BullsEye.app"}
+	for _, pth := range files {
+		target.Files = append(target.Files, xcresult.FileCoverage{Name: filepath.Base(pth), Path: pth})
+	}
+	return &xcresult.CoverageReport{Targets: []xcresult.TargetCoverage{target}}
+}
+
+func Test_combineCodeCoverage(t *testing.T) {
+	reports := []*xcresult.CoverageReport{
+		coverageReportOf(gameSwiftPath, contentSwiftPath),
+		coverageReportOf(gameSwiftPath),
+	}
+	lineCoverages := []map[string][]xcresult.LineCoverage{
+		{
+			gameSwiftPath: {
+				{Line: 1},
+				{Line: 2, IsExecutable: true, ExecutionCount: 3},
+				{Line: 3, IsExecutable: true},
+			},
+			contentSwiftPath: {
+				{Line: 5, IsExecutable: true},
+			},
+		},
+		{
+			gameSwiftPath: {
+				{Line: 1},
+				{Line: 2, IsExecutable: true, ExecutionCount: 1},
+				{Line: 3, IsExecutable: true, ExecutionCount: 2},
+			},
+		},
+	}
+
+	coverage := combineCodeCoverage(reports, lineCoverages)
+
+	require.Equal(t, CodeCoverage{
+		LineCoverage:    66.67,
+		CoveredLines:    2,
+		ExecutableLines: 3,
+		Targets: []CodeCoverageTarget{{
+			Name:            "BullsEye.app",
+			LineCoverage:    66.67,
+			CoveredLines:    2,
+			ExecutableLines: 3,
+			Files: []CodeCoverageFile{
+				{
+					Path:            gameSwiftPath,
+					LineCoverage:    100,
+					CoveredLines:    2,
+					ExecutableLines: 2,
+					lines: []xcresult.LineCoverage{
+						{Line: 2, IsExecutable: true, ExecutionCount: 4},
+						{Line: 3, IsExecutable: true, ExecutionCount: 2},
+					},
+				},
+				{
+					Path:            contentSwiftPath,
+					ExecutableLines: 1,
+					lines:           []xcresult.LineCoverage{{Line: 5, IsExecutable: true}},
+				},
+			},
+		}},
+	}, coverage)
+
+	require.Equal(t, "TN:\n"+
+		"SF:/src/BullsEye/BullsEyeGame.swift\n"+
+		"DA:2,4\n"+
+		"DA:3,2\n"+
+		"LF:2\n"+
+		"LH:2\n"+
+		"end_of_record\n"+
+		"TN:\n"+
+		"SF:/src/BullsEye/ContentView.swift\n"+
+		"DA:5,0\n"+
+		"LF:1\n"+
+		"LH:0\n"+
+		"end_of_record\n", createLcovReport(coverage))
+
+	content, err := xml.Marshal(createCoberturaReport(coverage, time.Unix(1736337372, 0)))
+	require.NoError(t, err)
+	require.Equal(t, `<coverage line-rate="0.6667" branch-rate="0" lines-covered="2" lines-valid="3" branches-covered="0" branches-valid="0" complexity="0" version="1.9" timestamp="1736337372">`+
+		`<packages><package name="BullsEye.app" line-rate="0.6667" branch-rate="0" complexity="0"><classes>`+
+		`<class name="BullsEyeGame" filename="/src/BullsEye/BullsEyeGame.swift" line-rate="1.0000" branch-rate="0" complexity="0"><methods></methods><lines><line number="2" hits="4"></line><line number="3" hits="2"></line></lines></class>`+
+		`<class name="ContentView" filename="/src/BullsEye/ContentView.swift" line-rate="0.0000" branch-rate="0" complexity="0"><methods></methods><lines><line number="5" hits="0"></line></lines></class>`+
+		`</classes></package></packages></coverage>`, string(content))
+}
+
+func Test_GivenCodeCoverageEnabled_WhenStepExportsOutputs_ThenCoverageReportsExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	expectTestSummaryAnnotation(testingMocks.commandFactory, mock.Anything)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-App.xcresult").Return(&xcresult.TestResults{}, nil)
+	testingMocks.xcresultReader.On("ReadCoverageReport", "/tmp/Test-App.xcresult").Return(coverageReportOf(gameSwiftPath), nil)
+	testingMocks.xcresultReader.On("ReadLineCoverage", "/tmp/Test-App.xcresult").Return(map[string][]xcresult.LineCoverage{
+		gameSwiftPath: {
+			{Line: 2, IsExecutable: true, ExecutionCount: 3},
+			{Line: 3, IsExecutable: true},
+		},
+	}, nil)
+
+	// When
+	err := step.ExportOutputs(Config{DeployDir: deployDir, EnableCodeCoverage: true}, []Result{{TestOutputDir: "/tmp/Test-App.xcresult", DeployDir: deployDir}})
+
+	// Then
+	require.NoError(t, err)
+	testingMocks.envRepository.AssertCalled(t, "Set", codeCoverageKey, "50.00")
+	testingMocks.envRepository.AssertCalled(t, "Set", targetCodeCoverageKey, `{"BullsEye.app":50}`)
+	for key, fileName := range map[string]string{
+		codeCoverageLcovKey:      "coverage.lcov",
+		codeCoverageCoberturaKey: "cobertura.xml",
+		codeCoverageSummaryKey:   "coverage_summary.json",
+	} {
+		pth := filepath.Join(deployDir, fileName)
+		require.FileExists(t, pth)
+		testingMocks.envRepository.AssertCalled(t, "Set", key, pth)
+	}
+
+	content, err := os.ReadFile(filepath.Join(deployDir, "coverage_summary.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"line_coverage": 50`)
+}
@@ -0,0 +1,97 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+)
+
+type destinationRun struct {
+	destination destination.Device
+	result      *Result
+	err         error
+}
+
+// DestinationResult is the result of a test run on a single destination, exported as a JSON list.
+type DestinationResult struct {
+	Destination      string `json:"destination"`
+	Name             string `json:"name"`
+	OS               string `json:"os"`
+	UDID             string `json:"udid"`
+	Xctestrun        string `json:"xctestrun"`
+	Result           string `json:"result"`
+	TestResultBundle string `json:"xcresult_path,omitempty"`
+}
+
+// runOnDestinations runs the tests of a single xctestrun on each destination. At most DestinationConcurrency
+// destinations are tested at the same time, the runs are returned in the order of the destinations.
+func (s XcodebuildTester) runOnDestinations(config Config) []destinationRun {
+	if len(config.Destinations) <= 1 {
+		result, err := s.Run(config)
+		return []destinationRun{{destination: config.Destination, result: result, err: err}}
+	}
+
+	concurrency := config.DestinationConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	runs := make([]destinationRun, len(config.Destinations))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, device := range config.Destinations {
+		runConfig := config
+		runConfig.Destination = device
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, runConfig Config) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			result, err := s.Run(runConfig)
+			runs[i] = destinationRun{destination: runConfig.Destination, result: result, err: err}
+		}(i, runConfig)
+	}
+	wg.Wait()
+
+	return runs
+}
+
+func destinationResults(results []Result) []DestinationResult {
+	var destinationResults []DestinationResult
+	for _, result := range results {
+		testResult := testResultSucceeded
+		if !result.Succeeded {
+			testResult = testResultFailed
+		}
+
+		destinationResults = append(destinationResults, DestinationResult{
+			Destination:      destinationName(result.Destination),
+			Name:             result.Destination.Name,
+			OS:               result.Destination.OS,
+			UDID:             result.Destination.ID,
+			Xctestrun:        result.Xctestrun,
+			Result:           testResult,
+			TestResultBundle: result.TestOutputDir,
+		})
+	}
+	return destinationResults
+}
+
+func destinationName(device destination.Device) string {
+	if device.OS == "" {
+		return device.Name
+	}
+	return fmt.Sprintf("%s (%s)", device.Name, device.OS)
+}
+
+// destinationFileName returns the destination's name and OS version, usable in a file name (for example iPhone-15-17.5).
+func destinationFileName(device destination.Device) string {
+	name := strings.Join(strings.Fields(device.Name+" "+device.OS), "-")
+	return replaceUnsupportedFilenameCharacters(name)
+}
@@ -0,0 +1,84 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenMultipleDestinations_WhenStepRuns_ThenTestsRunOnEachDestination(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests"))
+	require.NoError(t, err)
+
+	iPhone := destination.Device{ID: "iphone-UDID", Name: "iPhone 15", OS: "17.5"}
+	iPad := destination.Device{ID: "ipad-UDID", Name: "iPad Air (5th generation)", OS: "17.5"}
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Destination == iPhone && params.ResultBundleSuffix == "iPhone-15-17.5"
+	})).Return("Test-my_test-iPhone-15-17.5.xcresult", nil).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Destination == iPad && params.ResultBundleSuffix == "iPad-Air-(5th-generation)-17.5"
+	})).Return("Test-my_test-iPad-Air-(5th-generation)-17.5.xcresult", errors.New("failing tests (exit status 65)")).Once()
+
+	config := Config{
+		TestRuns:               []*xctestrun.TestRun{testRun},
+		Destinations:           []destination.Device{iPhone, iPad},
+		Destination:            iPhone,
+		DestinationConcurrency: 2,
+	}
+
+	// When
+	results, err := step.RunAll(config)
+
+	// Then
+	require.EqualError(t, err, "tests failed for 1 of 2 test runs: my_test.xctestrun on iPad Air (5th generation) (17.5)")
+	require.Len(t, results, 2)
+	require.Equal(t, iPhone, results[0].Destination)
+	require.True(t, results[0].Succeeded)
+	require.Equal(t, iPad, results[1].Destination)
+	require.False(t, results[1].Succeeded)
+	testingMocks.xcodebuild.AssertExpectations(t)
+}
+
+func Test_destinationResults(t *testing.T) {
+	results := []Result{
+		{
+			Xctestrun:     "/Build/Products/App.xctestrun",
+			Destination:   destination.Device{ID: "iphone-UDID", Name: "iPhone 15", OS: "17.5"},
+			Succeeded:     true,
+			TestOutputDir: "/tmp/Test-App-iPhone-15-17.5.xcresult",
+		},
+		{
+			Xctestrun:   "/Build/Products/App.xctestrun",
+			Destination: destination.Device{ID: "ipad-UDID", Name: "iPad Air (5th generation)", OS: "17.5"},
+		},
+	}
+
+	require.Equal(t, []DestinationResult{
+		{
+			Destination:      "iPhone 15 (17.5)",
+			Name:             "iPhone 15",
+			OS:               "17.5",
+			UDID:             "iphone-UDID",
+			Xctestrun:        "/Build/Products/App.xctestrun",
+			Result:           testResultSucceeded,
+			TestResultBundle: "/tmp/Test-App-iPhone-15-17.5.xcresult",
+		},
+		{
+			Destination: "iPad Air (5th generation) (17.5)",
+			Name:        "iPad Air (5th generation)",
+			OS:          "17.5",
+			UDID:        "ipad-UDID",
+			Xctestrun:   "/Build/Products/App.xctestrun",
+			Result:      testResultFailed,
+		},
+	}, destinationResults(results))
+}
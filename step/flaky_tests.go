@@ -0,0 +1,141 @@
+package step
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+const (
+	flakyTestReportFileName = "flaky_tests.json"
+
+	testStatusPassed = "passed"
+	testStatusFailed = "failed"
+	testStatusFlaky  = "flaky"
+)
+
+// FlakyTestReport is the classification of the tests run with test repetition:
+// a test is flaky if it both failed and passed, in the iterations of a test run or in the reruns of its failures.
+type FlakyTestReport struct {
+	PassedTestCount int          `json:"passed_test_count"`
+	FailedTestCount int          `json:"failed_test_count"`
+	FlakyTestCount  int          `json:"flaky_test_count"`
+	Tests           []TestReport `json:"tests"`
+}
+
+// TestReport is the classification of a test in a single test run (an xctestrun on a destination).
+type TestReport struct {
+	Identifier  string   `json:"identifier"`
+	Xctestrun   string   `json:"xctestrun,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+	Status      string   `json:"status"`
+	Results     []string `json:"results"`
+}
+
+// isRepeatingTests returns whether the tests can run more than once in a test run.
+func isRepeatingTests(config Config) bool {
+	return config.TestRepetitionMode != "" && config.TestRepetitionMode != xcodebuild.TestRepetitionNone
+}
+
+// classifyTests classifies the tests of each test run on its own: a test is classified by all of its iterations,
+// and all of its runs reported in the result bundle (like the merged result bundle of the reruns).
+// A test failing on one destination and passing on another is not flaky, it is reported for both test runs.
+// Skipped tests and expected failures are left out.
+func classifyTests(results []Result, reports map[string]*xcresult.TestResults) FlakyTestReport {
+	report := FlakyTestReport{Tests: []TestReport{}}
+	for _, result := range results {
+		testResults, ok := reports[result.TestOutputDir]
+		if !ok {
+			continue
+		}
+
+		resultsByTest := map[string][]string{}
+		for _, testCase := range testResults.TestCases() {
+			if len(testCase.Repetitions) > 0 {
+				resultsByTest[testCase.Identifier] = append(resultsByTest[testCase.Identifier], testCase.Repetitions...)
+			} else {
+				resultsByTest[testCase.Identifier] = append(resultsByTest[testCase.Identifier], testCase.Result)
+			}
+		}
+
+		var identifiers []string
+		for identifier := range resultsByTest {
+			identifiers = append(identifiers, identifier)
+		}
+		sort.Strings(identifiers)
+
+		for _, identifier := range identifiers {
+			status := testStatus(resultsByTest[identifier])
+			switch status {
+			case testStatusPassed:
+				report.PassedTestCount++
+			case testStatusFailed:
+				report.FailedTestCount++
+			case testStatusFlaky:
+				report.FlakyTestCount++
+			default:
+				continue
+			}
+
+			report.Tests = append(report.Tests, TestReport{
+				Identifier:  identifier,
+				Xctestrun:   result.Xctestrun,
+				Destination: destinationName(result.Destination),
+				Status:      status,
+				Results:     resultsByTest[identifier],
+			})
+		}
+	}
+	return report
+}
+
+func testStatus(results []string) string {
+	passed, failed := false, false
+	for _, result := range results {
+		switch result {
+		case xcresult.TestResultPassed:
+			passed = true
+		case xcresult.TestResultFailed:
+			failed = true
+		}
+	}
+
+	switch {
+	case passed && failed:
+		return testStatusFlaky
+	case failed:
+		return testStatusFailed
+	case passed:
+		return testStatusPassed
+	default:
+		return ""
+	}
+}
+
+func (s XcodebuildTester) exportFlakyTestReport(config Config, results []Result, reports map[string]*xcresult.TestResults) {
+	report := classifyTests(results, reports)
+
+	if report.FlakyTestCount > 0 {
+		s.logger.Warnf("Flaky tests:")
+		for _, test := range report.Tests {
+			if test.Status == testStatusFlaky {
+				s.logger.Warnf("- %s (%s)", test.Identifier, test.Destination)
+			}
+		}
+	}
+	s.exportOutput(flakyTestCountKey, strconv.Itoa(report.FlakyTestCount))
+
+	if config.DeployDir == "" {
+		return
+	}
+
+	pth := filepath.Join(config.DeployDir, flakyTestReportFileName)
+	if err := writeJSON(pth, report); err != nil {
+		s.logger.Warnf("Failed to export the flaky test report: %s", err)
+		return
+	}
+	s.exportOutput(flakyTestReportKey, pth)
+}
@@ -0,0 +1,122 @@
+package step
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_classifyTests(t *testing.T) {
+	iterations := &xcresult.TestResults{TestNodes: []xcresult.TestNode{{
+		NodeType: "Unit test bundle",
+		Name:     "AppTests",
+		Children: []xcresult.TestNode{{
+			NodeType: "Test Suite",
+			Name:     "LoginTests",
+			Children: []xcresult.TestNode{
+				{NodeType: "Test Case", Name: "testLogin()", Result: "Passed", Children: []xcresult.TestNode{
+					{NodeType: "Repetition", Name: "Retry 1", Result: "Failed"},
+					{NodeType: "Repetition", Name: "Retry 2", Result: "Passed"},
+				}},
+				{NodeType: "Test Case", Name: "testLogout()", Result: "Failed", Children: []xcresult.TestNode{
+					{NodeType: "Repetition", Name: "Retry 1", Result: "Failed"},
+					{NodeType: "Repetition", Name: "Retry 2", Result: "Failed"},
+				}},
+				{NodeType: "Test Case", Name: "testSignUp()", Result: "Passed"},
+				{NodeType: "Test Case", Name: "testLegacyLogin()", Result: "Skipped"},
+			},
+		}},
+	}}}
+	firstRun := testResultsOf(map[string]string{"AppTests/ProfileTests/testAvatar()": xcresult.TestResultFailed})
+	rerun := testResultsOf(map[string]string{"AppTests/ProfileTests/testAvatar()": xcresult.TestResultPassed})
+	merged := &xcresult.TestResults{TestNodes: append(firstRun.TestNodes, rerun.TestNodes...)}
+
+	device := destination.Device{Name: "iPhone 15", OS: "17.5"}
+	results := []Result{
+		{Xctestrun: "App.xctestrun", Destination: device, TestOutputDir: "Test-App.xcresult"},
+		{Xctestrun: "Profile.xctestrun", Destination: device, TestOutputDir: "Test-Profile.xcresult"},
+	}
+	reports := map[string]*xcresult.TestResults{
+		"Test-App.xcresult":     iterations,
+		"Test-Profile.xcresult": merged,
+	}
+
+	report := classifyTests(results, reports)
+
+	require.Equal(t, FlakyTestReport{
+		PassedTestCount: 1,
+		FailedTestCount: 1,
+		FlakyTestCount:  2,
+		Tests: []TestReport{
+			{Identifier: "AppTests/LoginTests/testLogin", Xctestrun: "App.xctestrun", Destination: "iPhone 15 (17.5)", Status: testStatusFlaky, Results: []string{"Failed", "Passed"}},
+			{Identifier: "AppTests/LoginTests/testLogout", Xctestrun: "App.xctestrun", Destination: "iPhone 15 (17.5)", Status: testStatusFailed, Results: []string{"Failed", "Failed"}},
+			{Identifier: "AppTests/LoginTests/testSignUp", Xctestrun: "App.xctestrun", Destination: "iPhone 15 (17.5)", Status: testStatusPassed, Results: []string{"Passed"}},
+			{Identifier: "AppTests/ProfileTests/testAvatar", Xctestrun: "Profile.xctestrun", Destination: "iPhone 15 (17.5)", Status: testStatusFlaky, Results: []string{"Failed", "Passed"}},
+		},
+	}, report)
+}
+
+func Test_GivenTestFailingOnOneDestination_WhenClassifyingTests_ThenTestNotFlaky(t *testing.T) {
+	// Given
+	results := []Result{
+		{Xctestrun: "App.xctestrun", Destination: destination.Device{Name: "iPhone 15", OS: "17.5"}, TestOutputDir: "Test-App-iPhone-15-17.5.xcresult"},
+		{Xctestrun: "App.xctestrun", Destination: destination.Device{Name: "iPhone 15", OS: "18.0"}, TestOutputDir: "Test-App-iPhone-15-18.0.xcresult"},
+	}
+	reports := map[string]*xcresult.TestResults{
+		"Test-App-iPhone-15-17.5.xcresult": testResultsOf(map[string]string{"AppTests/LoginTests/testLogin()": xcresult.TestResultFailed}),
+		"Test-App-iPhone-15-18.0.xcresult": testResultsOf(map[string]string{"AppTests/LoginTests/testLogin()": xcresult.TestResultPassed}),
+	}
+
+	// When
+	report := classifyTests(results, reports)
+
+	// Then
+	require.Equal(t, FlakyTestReport{
+		PassedTestCount: 1,
+		FailedTestCount: 1,
+		Tests: []TestReport{
+			{Identifier: "AppTests/LoginTests/testLogin", Xctestrun: "App.xctestrun", Destination: "iPhone 15 (17.5)", Status: testStatusFailed, Results: []string{"Failed"}},
+			{Identifier: "AppTests/LoginTests/testLogin", Xctestrun: "App.xctestrun", Destination: "iPhone 15 (18.0)", Status: testStatusPassed, Results: []string{"Passed"}},
+		},
+	}, report)
+}
+
+func Test_GivenTestRepetition_WhenStepExportsOutputs_ThenFlakyTestReportExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	expectTestSummaryAnnotation(testingMocks.commandFactory, mock.Anything)
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()": xcresult.TestResultPassed,
+	}), nil)
+
+	config := Config{
+		DeployDir:          deployDir,
+		TestRepetitionMode: xcodebuild.TestRepetitionRetryOnFailure,
+	}
+
+	// When
+	err := step.ExportOutputs(config, []Result{{TestOutputDir: "Test-App.xcresult", DeployDir: deployDir, Succeeded: true}})
+
+	// Then
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(deployDir, flakyTestReportFileName))
+	require.NoError(t, err)
+	var report FlakyTestReport
+	require.NoError(t, json.Unmarshal(content, &report))
+	require.Equal(t, 1, report.PassedTestCount)
+
+	testingMocks.envRepository.AssertCalled(t, "Set", flakyTestCountKey, "0")
+	testingMocks.envRepository.AssertCalled(t, "Set", flakyTestReportKey, filepath.Join(deployDir, flakyTestReportFileName))
+}
@@ -0,0 +1,144 @@
+package step
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+const junitReportFileSuffix = "-junit.xml"
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+
+	duration float64
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Skipped   *junitSkipped  `xml:"skipped"`
+}
+
+// junitFailure is a failure of a test case, the failure messages of the result bundle start with the file name and line number.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct{}
+
+// createJUnitReport converts the test report of a result bundle to a JUnit report, with one test suite per test class.
+func createJUnitReport(name string, testResults *xcresult.TestResults) junitTestSuites {
+	report := junitTestSuites{Name: name}
+
+	suiteIndexes := map[string]int{}
+	var totalDuration float64
+	for _, testCase := range testResults.TestCases() {
+		suiteName := testCase.Target + "." + testCase.Class
+		index, ok := suiteIndexes[suiteName]
+		if !ok {
+			index = len(report.TestSuites)
+			suiteIndexes[suiteName] = index
+			report.TestSuites = append(report.TestSuites, junitTestSuite{Name: suiteName})
+		}
+		suite := &report.TestSuites[index]
+
+		junitCase := junitTestCase{
+			Name:      strings.TrimSuffix(testCase.Name, "()"),
+			ClassName: suiteName,
+			Time:      junitTime(testCase.Duration.Seconds()),
+		}
+		switch testCase.Result {
+		case xcresult.TestResultFailed:
+			messages := testCase.Failures
+			if len(messages) == 0 {
+				messages = []string{"Test failed"}
+			}
+			for _, message := range messages {
+				junitCase.Failures = append(junitCase.Failures, junitFailure{Message: message, Text: message})
+			}
+			suite.Failures++
+		case xcresult.TestResultSkipped:
+			junitCase.Skipped = &junitSkipped{}
+			suite.Skipped++
+		}
+
+		suite.TestCases = append(suite.TestCases, junitCase)
+		suite.Tests++
+		suite.duration += testCase.Duration.Seconds()
+		totalDuration += testCase.Duration.Seconds()
+	}
+
+	for i := range report.TestSuites {
+		suite := &report.TestSuites[i]
+		suite.Time = junitTime(suite.duration)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+	report.Time = junitTime(totalDuration)
+
+	return report
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// exportJUnitReports writes the JUnit report of each test result bundle to the deploy dir.
+func (s XcodebuildTester) exportJUnitReports(results []Result, reports map[string]*xcresult.TestResults) {
+	var pths []string
+	for _, result := range results {
+		testResults, ok := reports[result.TestOutputDir]
+		if !ok || result.DeployDir == "" {
+			continue
+		}
+
+		pth, err := writeJUnitReport(result, testResults)
+		if err != nil {
+			s.logger.Warnf("Failed to export the JUnit report of %s: %s", result.TestOutputDir, err)
+			continue
+		}
+		pths = append(pths, pth)
+	}
+
+	if len(pths) > 0 {
+		s.exportOutput(junitReportKey, strings.Join(pths, "|"))
+	}
+}
+
+func writeJUnitReport(result Result, testResults *xcresult.TestResults) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(result.TestOutputDir), filepath.Ext(result.TestOutputDir))
+	content, err := xml.MarshalIndent(createJUnitReport(name, testResults), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(result.DeployDir, name+junitReportFileSuffix)
+	if err := os.WriteFile(pth, append([]byte(xml.Header), content...), 0644); err != nil {
+		return "", err
+	}
+	return pth, nil
+}
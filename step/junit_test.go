@@ -0,0 +1,78 @@
+package step
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createJUnitReport(t *testing.T) {
+	testResults := &xcresult.TestResults{TestNodes: []xcresult.TestNode{{
+		NodeType: "Unit test bundle",
+		Name:     "AppTests",
+		Children: []xcresult.TestNode{
+			{
+				NodeType: "Test Suite",
+				Name:     "LoginTests",
+				Children: []xcresult.TestNode{
+					{NodeType: "Test Case", Name: "testLogin()", Result: "Passed", DurationInSeconds: 1.5},
+					{NodeType: "Test Case", Name: "testLogout()", Result: "Failed", DurationInSeconds: 0.25, Children: []xcresult.TestNode{
+						{NodeType: "Failure Message", Name: "LoginTests.swift:42: XCTAssertTrue failed"},
+					}},
+				},
+			},
+			{
+				NodeType: "Test Suite",
+				Name:     "ProfileTests",
+				Children: []xcresult.TestNode{
+					{NodeType: "Test Case", Name: "testAvatar()", Result: "Skipped"},
+				},
+			},
+		},
+	}}}
+
+	content, err := xml.MarshalIndent(createJUnitReport("Test-App", testResults), "", "  ")
+	require.NoError(t, err)
+	require.Equal(t, `<testsuites name="Test-App" tests="3" failures="1" skipped="1" time="1.750">
+  <testsuite name="AppTests.LoginTests" tests="2" failures="1" skipped="0" time="1.750">
+    <testcase name="testLogin" classname="AppTests.LoginTests" time="1.500"></testcase>
+    <testcase name="testLogout" classname="AppTests.LoginTests" time="0.250">
+      <failure message="LoginTests.swift:42: XCTAssertTrue failed">LoginTests.swift:42: XCTAssertTrue failed</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="AppTests.ProfileTests" tests="1" failures="0" skipped="1" time="0.000">
+    <testcase name="testAvatar" classname="AppTests.ProfileTests" time="0.000">
+      <skipped></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>`, string(content))
+}
+
+func Test_GivenJUnitReportEnabled_WhenStepExportsOutputs_ThenReportWrittenToDeployDir(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()": xcresult.TestResultFailed,
+	}), nil)
+
+	// When
+	err := step.ExportOutputs(Config{GenerateJUnitReport: true}, []Result{{TestOutputDir: "/tmp/Test-App.xcresult", DeployDir: deployDir}})
+
+	// Then
+	require.NoError(t, err)
+
+	pth := filepath.Join(deployDir, "Test-App-junit.xml")
+	content, err := os.ReadFile(pth)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `<failure message="Test failed">Test failed</failure>`)
+	testingMocks.envRepository.AssertCalled(t, "Set", junitReportKey, pth)
+}
@@ -16,6 +16,7 @@ import (
 type OutputExporter interface {
 	ZipAndExportOutput(artifact, destinationZipPth, envKey string) error
 	CopyAndSaveTestData(artifact, targetAddonPath, testName string) error
+	CopyFile(src, dst string) error
 }
 
 type outputExporter struct {
@@ -42,6 +43,14 @@ func (e outputExporter) CopyAndSaveTestData(artifact, targetAddonPath, testName
 	return nil
 }
 
+func (e outputExporter) CopyFile(src, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, 0644)
+}
+
 // Replaces characters '/' and ':', which are unsupported in filenames on macOS
 func replaceUnsupportedFilenameCharacters(s string) string {
 	s = strings.Replace(s, "/", "-", -1)
@@ -0,0 +1,72 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+)
+
+// ConfigurationError means that the tests can't be started because of an invalid configuration.
+// Unlike xcodebuild.XcodebuildError, it never triggers an automatic retry.
+type ConfigurationError struct {
+	Reason  string
+	Details []string
+}
+
+func (err *ConfigurationError) Error() string {
+	if len(err.Details) == 0 {
+		return err.Reason
+	}
+	return err.Reason + ":\n- " + strings.Join(err.Details, "\n- ")
+}
+
+// checkTestProducts checks that the test hosts, test bundles, UI test target apps and other dependent products
+// referenced by the xctestrun exist.
+func (s XcodebuildTester) checkTestProducts(testRun xctestrun.TestRun) error {
+	type product struct {
+		kind string
+		pth  string
+	}
+
+	var missing []string
+	for _, configuration := range testRun.TestConfigurations {
+		for _, target := range configuration.TestTargets {
+			products := []product{
+				{kind: "test host", pth: target.TestHostPath},
+				{kind: "test bundle", pth: target.TestBundlePath},
+				{kind: "UI test target app", pth: target.UITargetAppPath},
+			}
+			for _, pth := range target.DependentProductPaths {
+				products = append(products, product{kind: "dependent product", pth: pth})
+			}
+
+			for _, product := range products {
+				if product.pth == "" {
+					continue
+				}
+
+				pth, ok := testRun.ResolvePath(target, product.pth)
+				if !ok {
+					continue
+				}
+
+				exists, err := s.pathChecker.IsPathExists(pth)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					missing = append(missing, fmt.Sprintf("%s of %s: %s", product.kind, target.BlueprintName, pth))
+				}
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return &ConfigurationError{
+			Reason:  fmt.Sprintf("build products referenced by %s are missing, make sure the build-for-testing products are available at the xctestrun's location", testRun.Path),
+			Details: uniqueStrings(missing),
+		}
+	}
+	return nil
+}
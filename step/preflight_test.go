@@ -0,0 +1,137 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenMissingTestProducts_WhenStepRuns_ThenConfigurationErrorReturned(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(testRoot, "Debug-iphonesimulator", "AppUITests-Runner.app"), 0755))
+
+	testRun := &xctestrun.TestRun{
+		Path: filepath.Join(testRoot, "App.xctestrun"),
+		TestConfigurations: []xctestrun.TestConfiguration{{TestTargets: []xctestrun.TestTarget{
+			{
+				BlueprintName:  "AppTests",
+				TestHostPath:   "__TESTROOT__/Debug-iphonesimulator/App.app",
+				TestBundlePath: "__TESTHOST__/PlugIns/AppTests.xctest",
+			},
+			{
+				BlueprintName:   "AppUITests",
+				TestHostPath:    "__TESTROOT__/Debug-iphonesimulator/AppUITests-Runner.app",
+				TestBundlePath:  "__TESTHOST__/PlugIns/AppUITests.xctest",
+				UITargetAppPath: "__TESTROOT__/Debug-iphonesimulator/App.app",
+				IsUITestBundle:  true,
+			},
+		}}},
+	}
+
+	config := Config{
+		Xctestrun:   testRun.Path,
+		TestRun:     testRun,
+		Destination: destination.Device{ID: "test-UDID"},
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	var configErr *ConfigurationError
+	require.True(t, errors.As(err, &configErr))
+	require.Equal(t, []string{
+		"test host of AppTests: " + filepath.Join(testRoot, "Debug-iphonesimulator", "App.app"),
+		"test bundle of AppTests: " + filepath.Join(testRoot, "Debug-iphonesimulator", "App.app", "PlugIns", "AppTests.xctest"),
+		"test bundle of AppUITests: " + filepath.Join(testRoot, "Debug-iphonesimulator", "AppUITests-Runner.app", "PlugIns", "AppUITests.xctest"),
+		"UI test target app of AppUITests: " + filepath.Join(testRoot, "Debug-iphonesimulator", "App.app"),
+	}, configErr.Details)
+	require.False(t, result.Succeeded)
+	testingMocks.xcodebuild.AssertNotCalled(t, "TestWithoutBuilding")
+}
+
+func Test_GivenMissingDependentProducts_WhenChecked_ThenConfigurationErrorReturned(t *testing.T) {
+	step, _ := createStepAndMocks(t)
+
+	testRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(testRoot, "Debug-iphonesimulator", "App.app"), 0755))
+
+	testRun := xctestrun.TestRun{
+		Path: filepath.Join(testRoot, "App.xctestrun"),
+		TestConfigurations: []xctestrun.TestConfiguration{{TestTargets: []xctestrun.TestTarget{{
+			BlueprintName: "AppTests",
+			TestHostPath:  "__TESTROOT__/Debug-iphonesimulator/App.app",
+			DependentProductPaths: []string{
+				"__TESTROOT__/Debug-iphonesimulator/App.app",
+				"__TESTROOT__/Debug-iphonesimulator/AppKit.framework",
+			},
+		}}}},
+	}
+
+	err := step.checkTestProducts(testRun)
+	var configErr *ConfigurationError
+	require.True(t, errors.As(err, &configErr))
+	require.Equal(t, []string{"dependent product of AppTests: " + filepath.Join(testRoot, "Debug-iphonesimulator", "AppKit.framework")}, configErr.Details)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(testRoot, "Debug-iphonesimulator", "AppKit.framework"), 0755))
+	require.NoError(t, step.checkTestProducts(testRun))
+}
+
+func Test_GivenTestProductsBundleWithMissingProducts_WhenStepRuns_ThenConfigurationErrorReturned(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	bundlePth := filepath.Join(t.TempDir(), "App.xctestproducts")
+	testRun := &xctestrun.TestRun{
+		Path:             filepath.Join(bundlePth, "Tests", "0", "App.xctestrun"),
+		TestProductsPath: bundlePth,
+		TestConfigurations: []xctestrun.TestConfiguration{{TestTargets: []xctestrun.TestTarget{{
+			BlueprintName:  "AppTests",
+			TestHostPath:   "__TESTROOT__/Debug-iphonesimulator/App.app",
+			TestBundlePath: "__TESTHOST__/PlugIns/AppTests.xctest",
+		}}}},
+	}
+
+	config := Config{
+		Xctestrun:   testRun.Path,
+		TestRun:     testRun,
+		Destination: destination.Device{ID: "test-UDID"},
+	}
+
+	// When
+	_, err := step.Run(config)
+
+	// Then
+	var configErr *ConfigurationError
+	require.True(t, errors.As(err, &configErr))
+	require.Equal(t, []string{
+		"test host of AppTests: " + filepath.Join(bundlePth, "Binaries", "0", "Debug-iphonesimulator", "App.app"),
+		"test bundle of AppTests: " + filepath.Join(bundlePth, "Binaries", "0", "Debug-iphonesimulator", "App.app", "PlugIns", "AppTests.xctest"),
+	}, configErr.Details)
+	testingMocks.xcodebuild.AssertNotCalled(t, "TestWithoutBuilding")
+}
+
+func Test_GivenPresentTestProducts_WhenChecked_ThenNoError(t *testing.T) {
+	step, _ := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests", "AppUITests"))
+	require.NoError(t, err)
+
+	require.NoError(t, step.checkTestProducts(*testRun))
+}
+
+func TestConfigurationError_Error(t *testing.T) {
+	err := &ConfigurationError{Reason: "build products are missing", Details: []string{"test host of AppTests: App.app"}}
+	require.Equal(t, "build products are missing:\n- test host of AppTests: App.app", err.Error())
+
+	err = &ConfigurationError{Reason: "build products are missing"}
+	require.Equal(t, "build products are missing", err.Error())
+}
@@ -0,0 +1,61 @@
+package step
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+// checkQuarantinedFailures reads the failed tests of the test result bundle and returns the failed quarantined tests.
+// If every failed test is quarantined, the test error is dropped, otherwise it is returned as is.
+// Failing test runs without failed tests (like a crashed test runner) are never quarantined.
+func (s XcodebuildTester) checkQuarantinedFailures(quarantinedTests []string, outputDir string, testErr error) ([]string, error) {
+	testResults, err := s.xcresultReader.ReadTestResults(outputDir)
+	if err != nil {
+		s.logger.Warnf("Failed to read the failed tests, quarantine is not applied: %s", err)
+		return nil, testErr
+	}
+
+	failed := map[string]bool{}
+	for _, testCase := range testResults.TestCases() {
+		if testCase.Result == xcresult.TestResultFailed {
+			failed[testCase.Identifier] = true
+		}
+	}
+
+	var quarantinedFailures, otherFailures []string
+	for identifier := range failed {
+		if isQuarantined(identifier, quarantinedTests) {
+			quarantinedFailures = append(quarantinedFailures, identifier)
+		} else {
+			otherFailures = append(otherFailures, identifier)
+		}
+	}
+	sort.Strings(quarantinedFailures)
+
+	if len(quarantinedFailures) > 0 {
+		s.logger.Warnf("Quarantined tests failed:")
+		for _, identifier := range quarantinedFailures {
+			s.logger.Warnf("- %s", identifier)
+		}
+	}
+
+	if len(quarantinedFailures) == 0 || len(otherFailures) > 0 {
+		return quarantinedFailures, testErr
+	}
+
+	s.logger.Warnf("Only quarantined tests failed, the failures don't fail the step")
+	return quarantinedFailures, nil
+}
+
+// isQuarantined checks whether the test (TestTarget/TestClass/testMethod) is listed, or its test target or class is listed
+// in the quarantined test identifiers.
+func isQuarantined(identifier string, quarantinedTests []string) bool {
+	for _, quarantined := range quarantinedTests {
+		if identifier == quarantined || strings.HasPrefix(identifier, quarantined+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_isQuarantined(t *testing.T) {
+	quarantinedTests := []string{"AppUITests", "AppTests/LoginTests", "AppTests/ProfileTests/testAvatar"}
+
+	require.True(t, isQuarantined("AppUITests/OnboardingTests/testSkip", quarantinedTests))
+	require.True(t, isQuarantined("AppTests/LoginTests/testLogin", quarantinedTests))
+	require.True(t, isQuarantined("AppTests/ProfileTests/testAvatar", quarantinedTests))
+	require.False(t, isQuarantined("AppTests/ProfileTests/testAvatarUpload", quarantinedTests))
+	require.False(t, isQuarantined("AppTests/LoginTestsExtra/testLogin", quarantinedTests))
+}
+
+func Test_GivenQuarantinedTests_WhenRunFails(t *testing.T) {
+	tests := []struct {
+		name                    string
+		results                 map[string]string
+		wantErr                 bool
+		wantQuarantinedFailures []string
+	}{
+		{
+			name: "only quarantined tests failed",
+			results: map[string]string{
+				"AppTests/LoginTests/testLogin()":  xcresult.TestResultFailed,
+				"AppTests/LoginTests/testLogout()": xcresult.TestResultFailed,
+				"AppTests/ProfileTests/testName()": xcresult.TestResultPassed,
+			},
+			wantQuarantinedFailures: []string{"AppTests/LoginTests/testLogin", "AppTests/LoginTests/testLogout"},
+		},
+		{
+			name: "other tests failed",
+			results: map[string]string{
+				"AppTests/LoginTests/testLogin()":  xcresult.TestResultFailed,
+				"AppTests/ProfileTests/testName()": xcresult.TestResultFailed,
+			},
+			wantErr:                 true,
+			wantQuarantinedFailures: []string{"AppTests/LoginTests/testLogin"},
+		},
+		{
+			name: "no failed tests",
+			results: map[string]string{
+				"AppTests/LoginTests/testLogin()": xcresult.TestResultPassed,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			step, testingMocks := createStepAndMocks(t)
+
+			testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("Test-App.xcresult", &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 65)", ExitCode: 65})
+			testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(testResultsOf(tt.results), nil)
+
+			config := Config{
+				Xctestrun:        "App.xctestrun",
+				Destination:      destination.Device{ID: "test-UDID"},
+				QuarantinedTests: []string{"AppTests/LoginTests"},
+			}
+
+			// When
+			result, err := step.Run(config)
+
+			// Then
+			if tt.wantErr {
+				require.EqualError(t, err, "failing tests (exit status 65)")
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, !tt.wantErr, result.Succeeded)
+			require.Equal(t, tt.wantQuarantinedFailures, result.QuarantinedFailures)
+		})
+	}
+}
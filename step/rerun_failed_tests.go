@@ -0,0 +1,106 @@
+package step
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+// testRepetitionRerunFailedTests reruns the failed tests in new xcodebuild processes,
+// unlike xcodebuild's retry_on_failure, which retries them in the same test runner process.
+const testRepetitionRerunFailedTests = "rerun_failed_tests"
+
+// rerunFailedTests reruns the failed tests of the test result bundle with -only-testing, until all of them pass
+// or the tests were run maximum_test_repetitions times. The verdict is based on the last result of each test,
+// the result bundles of the reruns are merged into a single one.
+func (s XcodebuildTester) rerunFailedTests(config Config, params xcodebuild.TestParams, outputDir string, attempts []Attempt, testErr error) (string, []Attempt, error) {
+	if testErr == nil || outputDir == "" {
+		return outputDir, attempts, testErr
+	}
+
+	lastResults := map[string]string{}
+	if err := s.readLastResults(outputDir, lastResults); err != nil {
+		s.logger.Warnf("Failed to read the failed tests, not rerunning them: %s", err)
+		return outputDir, attempts, testErr
+	}
+
+	outputDirs := []string{outputDir}
+	for round := 1; round < config.MaximumTestRepetitions; round++ {
+		failed := failedTests(lastResults)
+		if len(failed) == 0 {
+			break
+		}
+
+		s.logger.Println()
+		s.logger.Infof("Rerunning %d failed tests (round %d of %d):", len(failed), round, config.MaximumTestRepetitions-1)
+		for _, identifier := range failed {
+			s.logger.Printf("- %s", identifier)
+		}
+
+		rerunParams := params
+		rerunParams.OnlyTesting = failed
+		rerunParams.ResultBundleSuffix = strings.TrimPrefix(fmt.Sprintf("%s-rerun-%d", params.ResultBundleSuffix, round), "-")
+
+		rerunOutputDir, rerunAttempts, err := s.runTests(config, rerunParams)
+		if len(rerunAttempts) > 0 && rerunAttempts[0].Reason == "" {
+			rerunAttempts[0].Reason = testRepetitionRerunFailedTests
+		}
+		attempts = append(attempts, rerunAttempts...)
+		testErr = err
+
+		if rerunOutputDir == "" {
+			break
+		}
+		outputDirs = append(outputDirs, rerunOutputDir)
+
+		if err := s.readLastResults(rerunOutputDir, lastResults); err != nil {
+			s.logger.Warnf("Failed to read the results of the rerun: %s", err)
+			break
+		}
+	}
+
+	if len(outputDirs) == 1 {
+		s.logger.Warnf("No failed tests were rerun, the verdict is based on the first run")
+		return outputDir, attempts, testErr
+	}
+
+	mergedOutputDir, err := s.mergeResultBundles(outputDirs, params)
+	if err != nil {
+		s.logger.Warnf("Failed to merge the result bundles of the reruns: %s", err)
+		mergedOutputDir = outputDirs[len(outputDirs)-1]
+	}
+
+	if failed := failedTests(lastResults); len(failed) > 0 {
+		return mergedOutputDir, attempts, fmt.Errorf("%d tests failed after rerunning the failed tests: %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	s.logger.Donef("All the failed tests passed on rerun")
+	return mergedOutputDir, attempts, nil
+}
+
+// readLastResults updates the last results with the test results of the result bundle.
+func (s XcodebuildTester) readLastResults(outputDir string, lastResults map[string]string) error {
+	testResults, err := s.xcresultReader.ReadTestResults(outputDir)
+	if err != nil {
+		return err
+	}
+
+	for _, testCase := range testResults.TestCases() {
+		lastResults[testCase.Identifier] = testCase.Result
+	}
+	return nil
+}
+
+func failedTests(lastResults map[string]string) []string {
+	var failed []string
+	for identifier, result := range lastResults {
+		if result == xcresult.TestResultFailed {
+			failed = append(failed, identifier)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
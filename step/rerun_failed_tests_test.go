@@ -0,0 +1,128 @@
+package step
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenFailedTests_WhenRerunPasses_ThenTestsSucceed(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.ResultBundleSuffix == "" && params.TestRepetitionMode == xcodebuild.TestRepetitionNone
+	})).Return("Test-App.xcresult", &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 65)", ExitCode: 65}).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.ResultBundleSuffix == "rerun-1" && strings.Join(params.OnlyTesting, ",") == "AppTests/LoginTests/testLogin,AppTests/ProfileTests/testAvatar"
+	})).Return("Test-App-rerun-1.xcresult", nil).Once()
+
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()":    xcresult.TestResultFailed,
+		"AppTests/LoginTests/testLogout()":   xcresult.TestResultPassed,
+		"AppTests/ProfileTests/testAvatar()": xcresult.TestResultFailed,
+	}), nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App-rerun-1.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()":    xcresult.TestResultPassed,
+		"AppTests/ProfileTests/testAvatar()": xcresult.TestResultPassed,
+	}), nil)
+	testingMocks.xcresultMerger.On("Merge", []string{"Test-App.xcresult", "Test-App-rerun-1.xcresult"}, mock.MatchedBy(func(pth string) bool {
+		return strings.HasSuffix(pth, "/Test-App.xcresult")
+	})).Return(nil).Once()
+
+	config := Config{
+		Xctestrun:              "App.xctestrun",
+		Destination:            destination.Device{ID: "test-UDID"},
+		TestRepetitionMode:     testRepetitionRerunFailedTests,
+		MaximumTestRepetitions: 3,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, result.Succeeded)
+	require.Equal(t, 2, len(result.Attempts))
+	require.Equal(t, testRepetitionRerunFailedTests, result.Attempts[1].Reason)
+	testingMocks.xcodebuild.AssertExpectations(t)
+	testingMocks.xcresultMerger.AssertExpectations(t)
+}
+
+func Test_GivenFailedTests_WhenRerunsFail_ThenLastResultsReported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("Test-App.xcresult", &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 65)", ExitCode: 65}).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("Test-App-rerun-1.xcresult", &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 65)", ExitCode: 65}).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("Test-App-rerun-2.xcresult", &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 65)", ExitCode: 65}).Once()
+
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()":    xcresult.TestResultFailed,
+		"AppTests/ProfileTests/testAvatar()": xcresult.TestResultFailed,
+	}), nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App-rerun-1.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()":    xcresult.TestResultPassed,
+		"AppTests/ProfileTests/testAvatar()": xcresult.TestResultFailed,
+	}), nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App-rerun-2.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/ProfileTests/testAvatar()": xcresult.TestResultFailed,
+	}), nil)
+	testingMocks.xcresultMerger.On("Merge", []string{"Test-App.xcresult", "Test-App-rerun-1.xcresult", "Test-App-rerun-2.xcresult"}, mock.Anything).Return(nil).Once()
+
+	config := Config{
+		Xctestrun:              "App.xctestrun",
+		Destination:            destination.Device{ID: "test-UDID"},
+		TestRepetitionMode:     testRepetitionRerunFailedTests,
+		MaximumTestRepetitions: 3,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.EqualError(t, err, "1 tests failed after rerunning the failed tests: AppTests/ProfileTests/testAvatar")
+	require.False(t, result.Succeeded)
+	testingMocks.xcodebuild.AssertNumberOfCalls(t, "TestWithoutBuilding", 3)
+}
+
+func Test_GivenNoFailedTestsInResultBundle_WhenRerunningFailedTests_ThenOriginalErrorReturned(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testErr := &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 70)", ExitCode: 70}
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()": xcresult.TestResultPassed,
+	}), nil)
+
+	// When
+	outputDir, _, err := step.rerunFailedTests(Config{MaximumTestRepetitions: 3}, xcodebuild.TestParams{}, "Test-App.xcresult", nil, testErr)
+
+	// Then
+	require.Equal(t, testErr, err)
+	require.Equal(t, "Test-App.xcresult", outputDir)
+	testingMocks.xcodebuild.AssertNotCalled(t, "TestWithoutBuilding", mock.Anything)
+}
+
+// testResultsOf builds a test report from TestTarget/TestClass/testMethod() identifiers and their results.
+func testResultsOf(results map[string]string) *xcresult.TestResults {
+	var testResults xcresult.TestResults
+	for identifier, result := range results {
+		components := strings.Split(identifier, "/")
+		testResults.TestNodes = append(testResults.TestNodes, xcresult.TestNode{
+			NodeType: "Unit test bundle",
+			Name:     components[0],
+			Children: []xcresult.TestNode{{
+				NodeType: "Test Suite",
+				Name:     components[1],
+				Children: []xcresult.TestNode{{NodeType: "Test Case", Name: components[2], Result: result}},
+			}},
+		})
+	}
+	return &testResults
+}
@@ -0,0 +1,133 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	retryActionRetry          = "retry"
+	retryActionResetSimulator = "reset_simulator"
+	retryActionFailFast       = "fail_fast"
+
+	defaultMaxRetries = 1
+)
+
+// RetryPattern describes a known test runner failure: if the xcodebuild log matches the regex,
+// the action is taken (rerun the tests, reset the simulator and rerun the tests or stop retrying)
+// at most MaxRetries times.
+type RetryPattern struct {
+	Label      string `yaml:"label"`
+	Regex      string `yaml:"regex"`
+	MaxRetries *int   `yaml:"max_retries"`
+	Action     string `yaml:"action"`
+
+	regexp *regexp.Regexp
+}
+
+// defaultRetryPatterns returns the built-in test runner error patterns, the simulator is reset on simulator errors.
+func defaultRetryPatterns() []RetryPattern {
+	isSimulatorError := map[string]bool{}
+	for _, errorPattern := range simulatorErrorPatterns {
+		isSimulatorError[errorPattern] = true
+	}
+
+	var patterns []RetryPattern
+	for _, errorPattern := range testRunnerErrorPatterns {
+		action := retryActionRetry
+		if isSimulatorError[errorPattern] {
+			action = retryActionResetSimulator
+		}
+
+		pattern, err := newRetryPattern(RetryPattern{Label: errorPattern, Regex: errorPattern, Action: action})
+		if err != nil {
+			// The built-in patterns are covered by tests.
+			panic(err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// readRetryPatterns reads the retry patterns from a YAML or JSON file and merges them with the built-in patterns:
+// a pattern with the label of a built-in pattern replaces it, the other patterns are added to the end of the list.
+func readRetryPatterns(pth string) ([]RetryPattern, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retry patterns file: %w", err)
+	}
+
+	var filePatterns []RetryPattern
+	if err := yaml.Unmarshal(content, &filePatterns); err != nil {
+		return nil, fmt.Errorf("failed to parse retry patterns file (%s): %w", pth, err)
+	}
+
+	patterns := defaultRetryPatterns()
+	for i, filePattern := range filePatterns {
+		pattern, err := newRetryPattern(filePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry pattern (#%d): %w", i+1, err)
+		}
+
+		replaced := false
+		for j := range patterns {
+			if patterns[j].Label == pattern.Label {
+				patterns[j] = pattern
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns, nil
+}
+
+func newRetryPattern(pattern RetryPattern) (RetryPattern, error) {
+	if pattern.Regex == "" {
+		return RetryPattern{}, fmt.Errorf("regex is empty")
+	}
+
+	regex, err := regexp.Compile("(?i)" + pattern.Regex)
+	if err != nil {
+		return RetryPattern{}, fmt.Errorf("invalid regex (%s): %w", pattern.Regex, err)
+	}
+	pattern.regexp = regex
+
+	if pattern.Label == "" {
+		pattern.Label = pattern.Regex
+	}
+
+	if pattern.MaxRetries == nil {
+		maxRetries := defaultMaxRetries
+		pattern.MaxRetries = &maxRetries
+	} else if *pattern.MaxRetries < 0 {
+		return RetryPattern{}, fmt.Errorf("max_retries of %s can't be negative: %d", pattern.Label, *pattern.MaxRetries)
+	}
+
+	switch pattern.Action {
+	case "":
+		pattern.Action = retryActionRetry
+	case retryActionRetry, retryActionResetSimulator, retryActionFailFast:
+	default:
+		return RetryPattern{}, fmt.Errorf("invalid action of %s: %s, available actions: %s, %s, %s",
+			pattern.Label, pattern.Action, retryActionRetry, retryActionResetSimulator, retryActionFailFast)
+	}
+
+	return pattern, nil
+}
+
+// matchRetryPattern returns the first pattern matching the log.
+func matchRetryPattern(patterns []RetryPattern, log string) (RetryPattern, bool) {
+	for _, pattern := range patterns {
+		if pattern.regexp != nil && pattern.regexp.MatchString(log) {
+			return pattern, true
+		}
+	}
+	return RetryPattern{}, false
+}
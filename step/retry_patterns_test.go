@@ -0,0 +1,108 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRetryPatterns(t *testing.T) {
+	patterns := defaultRetryPatterns()
+
+	require.Equal(t, len(testRunnerErrorPatterns), len(patterns))
+	for i, pattern := range patterns {
+		require.Equal(t, testRunnerErrorPatterns[i], pattern.Label)
+		require.Equal(t, defaultMaxRetries, *pattern.MaxRetries)
+
+		switch pattern.Label {
+		case earlyUnexpectedExit, failedToOpenTestRunner:
+			require.Equal(t, retryActionResetSimulator, pattern.Action)
+		default:
+			require.Equal(t, retryActionRetry, pattern.Action)
+		}
+	}
+}
+
+func TestReadRetryPatterns(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		content     string
+		wantPattern RetryPattern
+		wantCount   int
+		wantErr     string
+	}{
+		{
+			name:     "YAML pattern added to the defaults",
+			fileName: "retry_patterns.yml",
+			content: `- label: Code signing
+  regex: Code Signature Invalid
+  action: fail_fast
+`,
+			wantPattern: RetryPattern{Label: "Code signing", Regex: "Code Signature Invalid", MaxRetries: intPtr(1), Action: retryActionFailFast},
+			wantCount:   len(testRunnerErrorPatterns) + 1,
+		},
+		{
+			name:        "JSON pattern overrides a default",
+			fileName:    "retry_patterns.json",
+			content:     `[{"label": "` + timeOutMessageIPhoneSimulator + `", "regex": "iPhoneSimulator: Timed out", "max_retries": 3, "action": "reset_simulator"}]`,
+			wantPattern: RetryPattern{Label: timeOutMessageIPhoneSimulator, Regex: "iPhoneSimulator: Timed out", MaxRetries: intPtr(3), Action: retryActionResetSimulator},
+			wantCount:   len(testRunnerErrorPatterns),
+		},
+		{
+			name:     "invalid regex",
+			fileName: "retry_patterns.yml",
+			content:  `- regex: "Test runner (exited"`,
+			wantErr:  "invalid retry pattern (#1): invalid regex (Test runner (exited)",
+		},
+		{
+			name:     "missing regex",
+			fileName: "retry_patterns.yml",
+			content:  `- label: Empty`,
+			wantErr:  "invalid retry pattern (#1): regex is empty",
+		},
+		{
+			name:     "invalid action",
+			fileName: "retry_patterns.yml",
+			content:  `- {regex: crashed, action: reboot}`,
+			wantErr:  "invalid retry pattern (#1): invalid action of crashed: reboot",
+		},
+		{
+			name:     "negative max retries",
+			fileName: "retry_patterns.yml",
+			content:  `- {regex: crashed, max_retries: -1}`,
+			wantErr:  "invalid retry pattern (#1): max_retries of crashed can't be negative: -1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), tt.fileName)
+			require.NoError(t, os.WriteFile(pth, []byte(tt.content), 0644))
+
+			patterns, err := readRetryPatterns(pth)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCount, len(patterns))
+
+			found := false
+			for _, pattern := range patterns {
+				if pattern.Label == tt.wantPattern.Label {
+					pattern.regexp = nil
+					require.Equal(t, tt.wantPattern, pattern)
+					found = true
+				}
+			}
+			require.True(t, found)
+		})
+	}
+}
+
+func intPtr(value int) *int {
+	return &value
+}
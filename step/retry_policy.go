@@ -0,0 +1,169 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+)
+
+const maxRetryDelay = 5 * time.Minute
+
+// Attempt is a single xcodebuild invocation of a test run.
+// Reason is the label of the retry pattern which triggered the attempt, it is empty for the first attempt.
+type Attempt struct {
+	Number       int     `json:"attempt"`
+	Simulator    string  `json:"simulator,omitempty"`
+	Reason       string  `json:"reason,omitempty"`
+	Duration     float64 `json:"duration_seconds"`
+	ExitCode     int     `json:"exit_code"`
+	ResultBundle string  `json:"xcresult_path,omitempty"`
+}
+
+// TestRunAttempts is the attempt history of a test run, exported as BITRISE_XCODE_TEST_ATTEMPTS.
+type TestRunAttempts struct {
+	Xctestrun   string    `json:"xctestrun"`
+	Destination string    `json:"destination"`
+	Attempts    []Attempt `json:"attempts"`
+}
+
+// retryPolicy decides whether a failed attempt is retried: the tests are retried if the xcodebuild log
+// matches a retry pattern, until the retry budget of the pattern or the maximum number of attempts runs out.
+// A succeeding attempt is never retried.
+type retryPolicy struct {
+	patterns    []RetryPattern
+	maxAttempts int
+	attempts    int
+	retries     map[string]int
+}
+
+// retryDecision is the outcome of a failed attempt. If Retry is false, Reason explains why the attempt isn't retried.
+type retryDecision struct {
+	Retry   bool
+	Pattern RetryPattern
+	Reason  string
+}
+
+// newRetryPolicy creates a retry policy, a non-positive maxAttempts doesn't limit the number of attempts.
+func newRetryPolicy(patterns []RetryPattern, maxAttempts int) *retryPolicy {
+	if patterns == nil {
+		patterns = defaultRetryPatterns()
+	}
+
+	return &retryPolicy{
+		patterns:    patterns,
+		maxAttempts: maxAttempts,
+		retries:     map[string]int{},
+	}
+}
+
+// next registers the outcome of an attempt and decides whether the tests are run again.
+func (p *retryPolicy) next(err error) retryDecision {
+	p.attempts++
+
+	if err == nil {
+		return retryDecision{}
+	}
+
+	var xcErr *xcodebuild.XcodebuildError
+	if !errors.As(err, &xcErr) {
+		return retryDecision{}
+	}
+
+	pattern, ok := matchRetryPattern(p.patterns, xcErr.Log)
+	if !ok {
+		return retryDecision{}
+	}
+
+	switch {
+	case pattern.Action == retryActionFailFast:
+		return retryDecision{Pattern: pattern, Reason: fmt.Sprintf("fail fast reason found in log: %s", pattern.Label)}
+	case p.retries[pattern.Label] >= *pattern.MaxRetries:
+		return retryDecision{Pattern: pattern, Reason: fmt.Sprintf("the retries of %s ran out (%d)", pattern.Label, *pattern.MaxRetries)}
+	case p.maxAttempts > 0 && p.attempts >= p.maxAttempts:
+		return retryDecision{Pattern: pattern, Reason: fmt.Sprintf("the maximum number of attempts (%d) reached", p.maxAttempts)}
+	}
+
+	p.retries[pattern.Label]++
+	return retryDecision{Retry: true, Pattern: pattern}
+}
+
+// retryDelay returns the delay before a retry: the base delay is doubled for each further retry, up to maxRetryDelay.
+func retryDelay(base time.Duration, retry int) time.Duration {
+	delay := base
+	for i := 1; i < retry && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// exitCodeOf returns the exit code of the xcodebuild command, 0 if it succeeded and -1 if it couldn't be run.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var xcErr *xcodebuild.XcodebuildError
+	if errors.As(err, &xcErr) {
+		return xcErr.ExitCode
+	}
+	return -1
+}
+
+// runTests runs the tests, and reruns them as long as the retry policy allows it.
+func (s XcodebuildTester) runTests(config Config, params xcodebuild.TestParams) (string, []Attempt, error) {
+	policy := newRetryPolicy(config.RetryPatterns, config.MaximumTestAttempts)
+
+	var (
+		attempts []Attempt
+		reason   string
+	)
+	for {
+		startTime := time.Now()
+		outputDir, err := s.xcodebuild.TestWithoutBuilding(params)
+		attempts = append(attempts, Attempt{
+			Number:       len(attempts) + 1,
+			Reason:       reason,
+			Duration:     time.Since(startTime).Seconds(),
+			ExitCode:     exitCodeOf(err),
+			ResultBundle: outputDir,
+		})
+
+		decision := policy.next(err)
+		if !decision.Retry {
+			if decision.Reason != "" {
+				s.logger.Warnf("Not retrying, %s", decision.Reason)
+			}
+			return outputDir, attempts, err
+		}
+
+		s.logger.Warnf("Automatic retry reason found in log: %s", decision.Pattern.Label)
+		if decision.Pattern.Action == retryActionResetSimulator {
+			s.logger.Printf("Resetting simulator: %s (%s)", params.Destination.Name, params.Destination.ID)
+			if err := s.simulatorManager.Reset(params.Destination); err != nil {
+				s.logger.Warnf("Failed to reset simulator: %s", err)
+			}
+		}
+		if delay := retryDelay(config.RetryDelay, len(attempts)); delay > 0 {
+			s.logger.Printf("Waiting %s before retrying", delay)
+			time.Sleep(delay)
+		}
+		reason = decision.Pattern.Label
+	}
+}
+
+func testRunAttempts(results []Result) []TestRunAttempts {
+	var runs []TestRunAttempts
+	for _, result := range results {
+		runs = append(runs, TestRunAttempts{
+			Xctestrun:   result.Xctestrun,
+			Destination: destinationName(result.Destination),
+			Attempts:    result.Attempts,
+		})
+	}
+	return runs
+}
@@ -0,0 +1,166 @@
+package step
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	runnerErr := &xcodebuild.XcodebuildError{Log: "Test runner never began executing tests after launching."}
+	crashErr := &xcodebuild.XcodebuildError{Log: "Early unexpected exit, operation never finished bootstrapping"}
+
+	twoRetries, err := newRetryPattern(RetryPattern{Label: "runner", Regex: "Test runner never began executing tests", MaxRetries: intPtr(2)})
+	require.NoError(t, err)
+	oneRetry, err := newRetryPattern(RetryPattern{Label: "crash", Regex: "Early unexpected exit"})
+	require.NoError(t, err)
+	patterns := []RetryPattern{twoRetries, oneRetry}
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		errs        []error
+		want        []bool
+		wantReason  string
+	}{
+		{
+			name: "success is not retried",
+			errs: []error{nil},
+			want: []bool{false},
+		},
+		{
+			name: "unknown failure is not retried",
+			errs: []error{errors.New("failed to create log file")},
+			want: []bool{false},
+		},
+		{
+			name:       "retries are budgeted per reason",
+			errs:       []error{runnerErr, crashErr, runnerErr, crashErr},
+			want:       []bool{true, true, true, false},
+			wantReason: "the retries of crash ran out (1)",
+		},
+		{
+			name:        "attempts are limited",
+			maxAttempts: 2,
+			errs:        []error{runnerErr, runnerErr},
+			want:        []bool{true, false},
+			wantReason:  "the maximum number of attempts (2) reached",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newRetryPolicy(patterns, tt.maxAttempts)
+
+			var decision retryDecision
+			for i, err := range tt.errs {
+				decision = policy.next(err)
+				require.Equal(t, tt.want[i], decision.Retry, "attempt %d", i+1)
+			}
+			require.Equal(t, tt.wantReason, decision.Reason)
+		})
+	}
+}
+
+func Test_retryDelay(t *testing.T) {
+	tests := []struct {
+		base  time.Duration
+		retry int
+		want  time.Duration
+	}{
+		{base: 0, retry: 3, want: 0},
+		{base: 10 * time.Second, retry: 1, want: 10 * time.Second},
+		{base: 10 * time.Second, retry: 3, want: 40 * time.Second},
+		{base: 2 * time.Minute, retry: 4, want: maxRetryDelay},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, retryDelay(tt.base, tt.retry), "base: %s, retry: %d", tt.base, tt.retry)
+	}
+}
+
+func Test_GivenSimulatorError_WhenRetrying_ThenSimulatorResetByDefault(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	device := destination.Device{ID: "test-UDID", Name: "iPhone 15"}
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("", &xcodebuild.XcodebuildError{Log: "Early unexpected exit, operation never finished bootstrapping - no restart will be attempted"}).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("Test-App.xcresult", nil).Once()
+	testingMocks.simulatorManager.On("Reset", device).Return(nil).Once()
+
+	// When
+	_, attempts, err := step.runTests(Config{}, xcodebuild.TestParams{Destination: device})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, earlyUnexpectedExit, attempts[1].Reason)
+	testingMocks.simulatorManager.AssertExpectations(t)
+}
+
+func Test_GivenResetSimulatorPattern_WhenLogMatches_ThenSimulatorResetBeforeRetry(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	device := destination.Device{ID: "test-UDID"}
+	pattern, err := newRetryPattern(RetryPattern{Regex: "Application failed preflight checks", MaxRetries: intPtr(2), Action: retryActionResetSimulator})
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("", &xcodebuild.XcodebuildError{Log: "Application failed preflight checks"})
+	testingMocks.simulatorManager.On("Reset", device).Return(nil)
+
+	// When
+	_, _, err = step.runTests(Config{RetryPatterns: []RetryPattern{pattern}}, xcodebuild.TestParams{Destination: device})
+
+	// Then
+	require.Error(t, err)
+	testingMocks.xcodebuild.AssertNumberOfCalls(t, "TestWithoutBuilding", 3)
+	testingMocks.simulatorManager.AssertNumberOfCalls(t, "Reset", 2)
+}
+
+func Test_GivenFailFastPattern_WhenLogMatches_ThenTestsNotRetried(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	failFast, err := newRetryPattern(RetryPattern{Regex: "Code Signature Invalid", Action: retryActionFailFast})
+	require.NoError(t, err)
+	patterns := append([]RetryPattern{failFast}, defaultRetryPatterns()...)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("", &xcodebuild.XcodebuildError{Log: "Code Signature Invalid\nTest runner never began executing tests after launching."})
+
+	// When
+	_, _, err = step.runTests(Config{RetryPatterns: patterns}, xcodebuild.TestParams{})
+
+	// Then
+	require.Error(t, err)
+	testingMocks.xcodebuild.AssertNumberOfCalls(t, "TestWithoutBuilding", 1)
+}
+
+func Test_GivenRetryPattern_WhenRetrySucceeds_ThenRetryingStops(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	pattern, err := newRetryPattern(RetryPattern{Regex: "Early unexpected exit", MaxRetries: intPtr(5)})
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("", &xcodebuild.XcodebuildError{Log: "Early unexpected exit", ExitCode: 65}).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("output.xcresult", nil).Once()
+
+	// When
+	outputDir, attempts, err := step.runTests(Config{RetryPatterns: []RetryPattern{pattern}}, xcodebuild.TestParams{})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "output.xcresult", outputDir)
+	require.Equal(t, 2, len(attempts))
+	require.Equal(t, Attempt{Number: 1, ExitCode: 65}, withoutDuration(attempts[0]))
+	require.Equal(t, Attempt{Number: 2, Reason: "Early unexpected exit", ResultBundle: "output.xcresult"}, withoutDuration(attempts[1]))
+	testingMocks.xcodebuild.AssertNumberOfCalls(t, "TestWithoutBuilding", 2)
+}
+
+func withoutDuration(attempt Attempt) Attempt {
+	attempt.Duration = 0
+	return attempt
+}
@@ -0,0 +1,167 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/simulator"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/hashicorp/go-version"
+	"howett.net/plist"
+)
+
+type minimumOSRequirement struct {
+	Version *version.Version
+	AppPath string
+}
+
+// checkRuntimeCompatibility compares the simulator's OS version with the minimum deployment target of the apps
+// launched by the tests. If the runtime is too old, it either fails or, if autoSelect is set,
+// returns the same device with the oldest installed runtime satisfying the deployment target.
+func (s XcodebuildTester) checkRuntimeCompatibility(testRuns []*xctestrun.TestRun, device destination.Device, autoSelect bool) (destination.Device, error) {
+	requirement, err := minimumOSVersion(testRuns)
+	if err != nil {
+		return destination.Device{}, err
+	}
+	if requirement == nil {
+		return device, nil
+	}
+
+	deviceOS, err := version.NewVersion(device.OS)
+	if err != nil {
+		s.logger.Warnf("Skipping runtime compatibility check, failed to parse simulator OS version (%s): %s", device.OS, err)
+		return device, nil
+	}
+	if !deviceOS.LessThan(requirement.Version) {
+		return device, nil
+	}
+
+	platform := strings.TrimSuffix(device.Platform, " Simulator")
+	if !autoSelect {
+		return destination.Device{}, &ConfigurationError{
+			Reason: fmt.Sprintf("the simulator runtime (%s %s) is older than the minimum deployment target (%s) of %s, "+
+				"select a newer OS in the destination input or enable the auto_select_compatible_runtime input",
+				platform, device.OS, requirement.Version.Original(), filepath.Base(requirement.AppPath)),
+		}
+	}
+
+	runtimes, err := s.simulatorManager.ListRuntimes()
+	if err != nil {
+		return destination.Device{}, err
+	}
+
+	runtime, ok := selectCompatibleRuntime(runtimes, platform, requirement.Version)
+	if !ok {
+		return destination.Device{}, &ConfigurationError{
+			Reason: fmt.Sprintf("none of the installed %s simulator runtimes satisfy the minimum deployment target (%s) of %s",
+				platform, requirement.Version.Original(), filepath.Base(requirement.AppPath)),
+		}
+	}
+
+	s.logger.Warnf("The simulator runtime (%s %s) is older than the minimum deployment target (%s) of %s, using %s instead",
+		platform, device.OS, requirement.Version.Original(), filepath.Base(requirement.AppPath), runtime.Name)
+
+	compatibleDevice, err := s.deviceFinder.FindDevice(destination.Simulator{
+		Platform: device.Platform,
+		Name:     device.Name,
+		OS:       runtime.Version,
+		Arch:     device.Arch,
+	})
+	if err != nil {
+		return destination.Device{}, fmt.Errorf("simulator UDID lookup failed: %w", err)
+	}
+
+	return compatibleDevice, nil
+}
+
+// minimumOSVersion returns the highest MinimumOSVersion of the test hosts and UI test target apps.
+// Apps without an Info.plist are skipped, missing build products are reported by the pre-flight check.
+func minimumOSVersion(testRuns []*xctestrun.TestRun) (*minimumOSRequirement, error) {
+	var requirement *minimumOSRequirement
+
+	for _, testRun := range testRuns {
+		for _, target := range testRun.TestTargets() {
+			for _, appPth := range []string{target.TestHostPath, target.UITargetAppPath} {
+				pth, ok := testRun.ResolvePath(target, appPth)
+				if !ok {
+					continue
+				}
+
+				minimumVersion, err := readMinimumOSVersion(filepath.Join(pth, "Info.plist"))
+				if err != nil {
+					return nil, err
+				}
+				if minimumVersion == nil {
+					continue
+				}
+
+				if requirement == nil || minimumVersion.GreaterThan(requirement.Version) {
+					requirement = &minimumOSRequirement{Version: minimumVersion, AppPath: pth}
+				}
+			}
+		}
+	}
+
+	return requirement, nil
+}
+
+func readMinimumOSVersion(infoPlistPth string) (*version.Version, error) {
+	content, err := os.ReadFile(infoPlistPth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read Info.plist: %w", err)
+	}
+
+	var infoPlist struct {
+		MinimumOSVersion string `plist:"MinimumOSVersion"`
+	}
+	if _, err := plist.Unmarshal(content, &infoPlist); err != nil {
+		return nil, fmt.Errorf("failed to parse Info.plist (%s): %w", infoPlistPth, err)
+	}
+	if infoPlist.MinimumOSVersion == "" {
+		return nil, nil
+	}
+
+	minimumVersion, err := version.NewVersion(infoPlist.MinimumOSVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MinimumOSVersion (%s) in %s: %w", infoPlist.MinimumOSVersion, infoPlistPth, err)
+	}
+	return minimumVersion, nil
+}
+
+// selectCompatibleRuntime returns the oldest available runtime of the platform which satisfies the minimum OS version.
+func selectCompatibleRuntime(runtimes []simulator.Runtime, platform string, minimumVersion *version.Version) (simulator.Runtime, bool) {
+	type candidate struct {
+		runtime simulator.Runtime
+		version *version.Version
+	}
+	var candidates []candidate
+
+	for _, runtime := range runtimes {
+		if !runtime.IsAvailable || !runtime.IsPlatform(platform) {
+			continue
+		}
+
+		runtimeVersion, err := version.NewVersion(runtime.Version)
+		if err != nil || runtimeVersion.LessThan(minimumVersion) {
+			continue
+		}
+
+		candidates = append(candidates, candidate{runtime: runtime, version: runtimeVersion})
+	}
+
+	if len(candidates) == 0 {
+		return simulator.Runtime{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].version.LessThan(candidates[j].version)
+	})
+	return candidates[0].runtime, true
+}
@@ -0,0 +1,143 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/simulator"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+	"howett.net/plist"
+)
+
+func Test_GivenOutdatedRuntime_WhenCheckingCompatibility_ThenConfigurationErrorReturned(t *testing.T) {
+	// Given
+	step, _ := createStepAndMocks(t)
+
+	testRun := parseXctestrunWithMinimumOSVersion(t, "16.0")
+	device := destination.Device{ID: "test-UDID", Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "15.5"}
+
+	// When
+	_, err := step.checkRuntimeCompatibility([]*xctestrun.TestRun{testRun}, device, false)
+
+	// Then
+	var configErr *ConfigurationError
+	require.True(t, errors.As(err, &configErr))
+	require.EqualError(t, err, "the simulator runtime (iOS 15.5) is older than the minimum deployment target (16.0) of App.app, "+
+		"select a newer OS in the destination input or enable the auto_select_compatible_runtime input")
+}
+
+func Test_GivenCompatibleRuntime_WhenCheckingCompatibility_ThenDeviceKept(t *testing.T) {
+	// Given
+	step, _ := createStepAndMocks(t)
+
+	testRun := parseXctestrunWithMinimumOSVersion(t, "16.0")
+	device := destination.Device{ID: "test-UDID", Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "16.4"}
+
+	// When
+	compatibleDevice, err := step.checkRuntimeCompatibility([]*xctestrun.TestRun{testRun}, device, false)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, device, compatibleDevice)
+}
+
+func Test_minimumOSVersion(t *testing.T) {
+	testRun := parseXctestrunWithMinimumOSVersion(t, "16.0")
+
+	requirement, err := minimumOSVersion([]*xctestrun.TestRun{testRun})
+	require.NoError(t, err)
+	require.Equal(t, "16.0", requirement.Version.Original())
+	require.Equal(t, filepath.Join(testRun.TestRoot(), "Debug-iphonesimulator", "App.app"), requirement.AppPath)
+
+	testRun, err = xctestrun.Parse(writeXctestrun(t, "AppTests"))
+	require.NoError(t, err)
+
+	requirement, err = minimumOSVersion([]*xctestrun.TestRun{testRun})
+	require.NoError(t, err)
+	require.Nil(t, requirement)
+}
+
+func Test_GivenTestProductsBundle_WhenReadingMinimumOSVersion_ThenBundleTestHostChecked(t *testing.T) {
+	bundlePth := filepath.Join(t.TempDir(), "App.xctestproducts")
+	appPth := filepath.Join(bundlePth, "Binaries", "0", "Debug-iphonesimulator", "App.app")
+	require.NoError(t, os.MkdirAll(appPth, 0755))
+
+	content, err := plist.Marshal(map[string]interface{}{"MinimumOSVersion": "17.0"}, plist.XMLFormat)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(appPth, "Info.plist"), content, 0644))
+
+	testRun := &xctestrun.TestRun{
+		Path:             filepath.Join(bundlePth, "Tests", "0", "App.xctestrun"),
+		TestProductsPath: bundlePth,
+		TestConfigurations: []xctestrun.TestConfiguration{{TestTargets: []xctestrun.TestTarget{{
+			BlueprintName: "AppTests",
+			TestHostPath:  "__TESTROOT__/Debug-iphonesimulator/App.app",
+		}}}},
+	}
+
+	requirement, err := minimumOSVersion([]*xctestrun.TestRun{testRun})
+	require.NoError(t, err)
+	require.Equal(t, "17.0", requirement.Version.Original())
+	require.Equal(t, appPth, requirement.AppPath)
+
+	step, _ := createStepAndMocks(t)
+	_, err = step.checkRuntimeCompatibility([]*xctestrun.TestRun{testRun}, destination.Device{ID: "test-UDID", Platform: "iOS Simulator", Name: "iPhone 15", OS: "16.4"}, false)
+	var configErr *ConfigurationError
+	require.True(t, errors.As(err, &configErr))
+}
+
+func Test_GivenOutdatedRuntimeAndAutoSelect_WhenCheckingCompatibility_ThenDeviceWithCompatibleRuntimeFound(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun := parseXctestrunWithMinimumOSVersion(t, "16.0")
+	device := destination.Device{ID: "test-UDID", Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "15.5", Arch: "arm64"}
+
+	testingMocks.simulatorManager.On("ListRuntimes").Return(testRuntimes, nil)
+	compatibleDevice := destination.Device{ID: "compatible-UDID", Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "16.4"}
+	testingMocks.deviceFinder.On("FindDevice", destination.Simulator{Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "16.4", Arch: "arm64"}).Return(compatibleDevice, nil)
+
+	// When
+	selected, err := step.checkRuntimeCompatibility([]*xctestrun.TestRun{testRun}, device, true)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, compatibleDevice, selected)
+	testingMocks.simulatorManager.AssertExpectations(t)
+}
+
+var testRuntimes = []simulator.Runtime{
+	{Identifier: "com.apple.CoreSimulator.SimRuntime.iOS-15-5", Platform: "iOS", Version: "15.5", Name: "iOS 15.5", IsAvailable: true},
+	{Identifier: "com.apple.CoreSimulator.SimRuntime.iOS-17-5", Platform: "iOS", Version: "17.5", Name: "iOS 17.5", IsAvailable: true},
+	{Identifier: "com.apple.CoreSimulator.SimRuntime.iOS-16-4", Platform: "iOS", Version: "16.4", Name: "iOS 16.4", IsAvailable: true},
+	{Identifier: "com.apple.CoreSimulator.SimRuntime.iOS-16-0", Platform: "iOS", Version: "16.0", Name: "iOS 16.0", IsAvailable: false},
+	{Identifier: "com.apple.CoreSimulator.SimRuntime.tvOS-16-1", Platform: "tvOS", Version: "16.1", Name: "tvOS 16.1", IsAvailable: true},
+}
+
+func Test_selectCompatibleRuntime(t *testing.T) {
+	runtimes := testRuntimes
+
+	runtime, ok := selectCompatibleRuntime(runtimes, "iOS", version.Must(version.NewVersion("16.0")))
+	require.True(t, ok)
+	require.Equal(t, "com.apple.CoreSimulator.SimRuntime.iOS-16-4", runtime.Identifier)
+
+	_, ok = selectCompatibleRuntime(runtimes, "iOS", version.Must(version.NewVersion("18.0")))
+	require.False(t, ok)
+}
+
+func parseXctestrunWithMinimumOSVersion(t *testing.T, minimumOSVersion string) *xctestrun.TestRun {
+	pth := writeXctestrun(t, "AppTests")
+
+	content, err := plist.Marshal(map[string]interface{}{"MinimumOSVersion": minimumOSVersion}, plist.XMLFormat)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(pth), "Debug-iphonesimulator", "App.app", "Info.plist"), content, 0644))
+
+	testRun, err := xctestrun.Parse(pth)
+	require.NoError(t, err)
+	return testRun
+}
@@ -0,0 +1,260 @@
+package step
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+)
+
+func validateShard(index, count int) error {
+	if count < 0 {
+		return fmt.Errorf("invalid shard_count input (%d): it can't be negative", count)
+	}
+	if index < 0 || (count > 1 && index >= count) || (count <= 1 && index > 0) {
+		return fmt.Errorf("invalid shard_index input (%d): it should be between 0 and %d", index, maxInt(count-1, 0))
+	}
+	return nil
+}
+
+// ShardAssignment is the split of the test units (test classes or targets) between the shards.
+type ShardAssignment struct {
+	ShardCount int     `json:"shard_count"`
+	Strategy   string  `json:"strategy"`
+	Shards     []Shard `json:"shards"`
+}
+
+type Shard struct {
+	Index             int      `json:"index"`
+	EstimatedDuration float64  `json:"estimated_duration_seconds,omitempty"`
+	TestUnits         []string `json:"tests"`
+}
+
+const (
+	shardingStrategyRoundRobin = "round_robin"
+	shardingStrategyTimings    = "timings"
+)
+
+// shardTests returns the test selection of the given shard and the assignment of all the shards.
+// Without test timings the sorted units are distributed in a round-robin way, with test timings they are
+// balanced by their durations. Either way, every shard gets the same split on every machine:
+// the test classes have to be enumerated, as a split by the test targets on some of the machines would leave tests out.
+// The selection of a shard without test units is empty, the shard has nothing to run.
+func (s XcodebuildTester) shardTests(config Config, index, count int) ([]string, *ShardAssignment, error) {
+	units, err := s.enumerateTestClasses(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to enumerate the tests to split between the shards: %w", err)
+	}
+	assignment := assignTestUnits(units, count, config.TestTimings)
+
+	s.printShardAssignment(assignment, index)
+
+	shardUnits := assignment.Shards[index].TestUnits
+	if len(shardUnits) == 0 {
+		s.logger.Warnf("Shard %d has no tests to run: %d test units were split into %d shards", index, len(units), count)
+		return nil, &assignment, nil
+	}
+
+	return narrowTestSelection(shardUnits, config.OnlyTesting), &assignment, nil
+}
+
+// isEmptyShard reports whether the tests are sharded and this shard got no tests to run.
+func (c Config) isEmptyShard() bool {
+	return c.ShardAssignment != nil && len(c.ShardAssignment.Shards[c.ShardIndex].TestUnits) == 0
+}
+
+func (s XcodebuildTester) printShardAssignment(assignment ShardAssignment, index int) {
+	s.logger.Println()
+	s.logger.Infof("Test shards (%s):", assignment.Strategy)
+	for _, shard := range assignment.Shards {
+		marker := ""
+		if shard.Index == index {
+			marker = " (this shard)"
+		}
+		if assignment.Strategy == shardingStrategyTimings {
+			s.logger.Printf("- shard %d%s: %d test units, ~%.1fs", shard.Index, marker, len(shard.TestUnits), shard.EstimatedDuration)
+		} else {
+			s.logger.Printf("- shard %d%s: %d test units", shard.Index, marker, len(shard.TestUnits))
+		}
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Tests of shard %d:", index)
+	for _, unit := range assignment.Shards[index].TestUnits {
+		s.logger.Printf("- %s", unit)
+	}
+}
+
+// testUnits returns the units the tests of the simulator clones can be split by: the test classes if they can be
+// enumerated (Xcode 15+), otherwise the test targets.
+func (s XcodebuildTester) testUnits(config Config) []string {
+	units, err := s.enumerateTestClasses(config)
+	if err != nil {
+		s.logger.Warnf("Failed to enumerate tests, splitting the tests by test targets: %s", err)
+	}
+	if len(units) == 0 {
+		units = testTargetUnits(config)
+	}
+	return units
+}
+
+func (s XcodebuildTester) enumerateTestClasses(config Config) ([]string, error) {
+	var classes []string
+	for _, testRun := range config.TestRuns {
+		runConfig, ok := config.forTestRun(testRun)
+		if !ok {
+			continue
+		}
+
+		params := xcodebuild.TestParams{
+			Xctestrun:             testRun.Path,
+			OnlyTesting:           runConfig.OnlyTesting,
+			SkipTesting:           runConfig.SkipTesting,
+			OnlyTestConfiguration: runConfig.OnlyTestConfiguration,
+			SkipTestConfiguration: runConfig.SkipTestConfiguration,
+			Destination:           config.Destination,
+		}
+		if testRun.TestProductsPath != "" {
+			params.TestProductsPath = testRun.TestProductsPath
+			if testRun.TestPlan != nil {
+				params.TestPlan = testRun.TestPlan.Name
+			}
+		}
+
+		tests, err := s.xcodebuild.EnumerateTests(params)
+		if err != nil {
+			return nil, err
+		}
+		for _, test := range tests {
+			classes = append(classes, testClassOf(test))
+		}
+	}
+
+	classes = uniqueStrings(classes)
+	sort.Strings(classes)
+	return classes, nil
+}
+
+// testTargetUnits returns the selected test targets, the fully skipped targets are left out.
+func testTargetUnits(config Config) []string {
+	selected := map[string]bool{}
+	for _, identifier := range config.OnlyTesting {
+		selected[testTargetOf(identifier)] = true
+	}
+	skipped := map[string]bool{}
+	for _, identifier := range config.SkipTesting {
+		if !strings.Contains(identifier, "/") {
+			skipped[identifier] = true
+		}
+	}
+
+	targets := filterStrings(testTargetNames(config.TestRuns), func(target string) bool {
+		return (len(selected) == 0 || selected[target]) && !skipped[target]
+	})
+	sort.Strings(targets)
+	return targets
+}
+
+// assignTestUnits splits the test units into count groups, balanced by their durations if test timings are available.
+func assignTestUnits(units []string, count int, timings TestTimings) ShardAssignment {
+	if len(timings.TestClasses) > 0 {
+		return assignByDuration(units, count, timings.estimateDurations(units))
+	}
+	return assignRoundRobin(units, count)
+}
+
+func newShardAssignment(count int, strategy string) ShardAssignment {
+	assignment := ShardAssignment{ShardCount: count, Strategy: strategy}
+	for i := 0; i < count; i++ {
+		assignment.Shards = append(assignment.Shards, Shard{Index: i})
+	}
+	return assignment
+}
+
+func assignRoundRobin(units []string, count int) ShardAssignment {
+	assignment := newShardAssignment(count, shardingStrategyRoundRobin)
+	for i, unit := range units {
+		shard := &assignment.Shards[i%count]
+		shard.TestUnits = append(shard.TestUnits, unit)
+	}
+	return assignment
+}
+
+// assignByDuration balances the shards with a greedy bin packing: the units are taken from the longest to the shortest
+// and each one goes to the shard with the least total duration so far (the lower index on ties).
+func assignByDuration(units []string, count int, durations map[string]float64) ShardAssignment {
+	sorted := append([]string{}, units...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if durations[sorted[i]] != durations[sorted[j]] {
+			return durations[sorted[i]] > durations[sorted[j]]
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	assignment := newShardAssignment(count, shardingStrategyTimings)
+	for _, unit := range sorted {
+		shortest := 0
+		for i, shard := range assignment.Shards {
+			if shard.EstimatedDuration < assignment.Shards[shortest].EstimatedDuration {
+				shortest = i
+			}
+		}
+
+		shard := &assignment.Shards[shortest]
+		shard.TestUnits = append(shard.TestUnits, unit)
+		shard.EstimatedDuration += durations[unit]
+	}
+
+	for i := range assignment.Shards {
+		sort.Strings(assignment.Shards[i].TestUnits)
+	}
+	return assignment
+}
+
+// narrowTestSelection converts the shard's test units (test targets or classes) into only_testing identifiers.
+// If a unit is only partially selected by the only_testing input, the selected identifiers within the unit are kept.
+func narrowTestSelection(units, onlyTesting []string) []string {
+	if len(onlyTesting) == 0 {
+		return units
+	}
+
+	var selection []string
+	for _, unit := range units {
+		var (
+			covered  bool
+			narrower []string
+		)
+		for _, identifier := range onlyTesting {
+			switch {
+			case identifier == unit || identifier == testTargetOf(unit):
+				covered = true
+			case strings.HasPrefix(identifier, unit+"/"):
+				narrower = append(narrower, identifier)
+			}
+		}
+
+		if covered {
+			selection = append(selection, unit)
+		} else {
+			selection = append(selection, narrower...)
+		}
+	}
+	return selection
+}
+
+// testClassOf returns the TestTarget/TestClass part of a test identifier.
+func testClassOf(identifier string) string {
+	components := strings.SplitN(identifier, "/", 3)
+	if len(components) < 2 {
+		return identifier
+	}
+	return components[0] + "/" + components[1]
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,250 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateShard(t *testing.T) {
+	tests := []struct {
+		name    string
+		index   int
+		count   int
+		wantErr string
+	}{
+		{name: "sharding disabled", index: 0, count: 1},
+		{name: "shard count not set", index: 0, count: 0},
+		{name: "last shard", index: 3, count: 4},
+		{name: "index out of range", index: 4, count: 4, wantErr: "invalid shard_index input (4): it should be between 0 and 3"},
+		{name: "negative index", index: -1, count: 4, wantErr: "invalid shard_index input (-1): it should be between 0 and 3"},
+		{name: "index without sharding", index: 1, count: 1, wantErr: "invalid shard_index input (1): it should be between 0 and 0"},
+		{name: "negative count", index: 0, count: -2, wantErr: "invalid shard_count input (-2): it can't be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateShard(tt.index, tt.count)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_assignRoundRobin(t *testing.T) {
+	units := []string{"A/A1", "A/A2", "B/B1", "B/B2", "C/C1"}
+
+	assignment := assignRoundRobin(units, 3)
+
+	require.Equal(t, ShardAssignment{
+		ShardCount: 3,
+		Strategy:   shardingStrategyRoundRobin,
+		Shards: []Shard{
+			{Index: 0, TestUnits: []string{"A/A1", "B/B2"}},
+			{Index: 1, TestUnits: []string{"A/A2", "C/C1"}},
+			{Index: 2, TestUnits: []string{"B/B1"}},
+		},
+	}, assignment)
+}
+
+func Test_assignByDuration(t *testing.T) {
+	durations := map[string]float64{
+		"A/Slow":   90,
+		"A/Medium": 40,
+		"B/Medium": 40,
+		"B/Fast":   10,
+		"C/Fast":   10,
+	}
+
+	assignment := assignByDuration([]string{"A/Medium", "A/Slow", "B/Fast", "B/Medium", "C/Fast"}, 2, durations)
+
+	require.Equal(t, ShardAssignment{
+		ShardCount: 2,
+		Strategy:   shardingStrategyTimings,
+		Shards: []Shard{
+			{Index: 0, EstimatedDuration: 100, TestUnits: []string{"A/Slow", "C/Fast"}},
+			{Index: 1, EstimatedDuration: 90, TestUnits: []string{"A/Medium", "B/Fast", "B/Medium"}},
+		},
+	}, assignment)
+}
+
+func Test_narrowTestSelection(t *testing.T) {
+	units := []string{"A/A1", "B/B1", "C/C1"}
+	onlyTesting := []string{"A", "B/B1/testOne", "B/B1/testTwo", "C/C1"}
+
+	require.Equal(t, units, narrowTestSelection(units, nil))
+	require.Equal(t, []string{"A/A1", "B/B1/testOne", "B/B1/testTwo", "C/C1"}, narrowTestSelection(units, onlyTesting))
+}
+
+func Test_GivenEnumeratedTests_WhenSharding_ThenSplitByTestClasses(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests", "AppUITests"))
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("EnumerateTests", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Xctestrun == testRun.Path && params.Destination.ID == "test-UDID"
+	})).Return([]string{
+		"AppTests/LoginTests/testLogin()",
+		"AppTests/LoginTests/testLogout()",
+		"AppTests/ProfileTests/testAvatar()",
+		"AppUITests/OnboardingTests/testSkip()",
+	}, nil)
+
+	config := Config{
+		TestRuns:    []*xctestrun.TestRun{testRun},
+		Destination: destination.Device{ID: "test-UDID"},
+	}
+
+	// When
+	shard0, assignment, err := step.shardTests(config, 0, 2)
+	require.NoError(t, err)
+	shard1, _, err := step.shardTests(config, 1, 2)
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, []string{"AppTests/LoginTests", "AppUITests/OnboardingTests"}, shard0)
+	require.Equal(t, []string{"AppTests/ProfileTests"}, shard1)
+	require.Equal(t, shardingStrategyRoundRobin, assignment.Strategy)
+}
+
+func Test_GivenTestTimings_WhenSharding_ThenShardsBalancedByDuration(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests", "AppUITests"))
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("EnumerateTests", mock.Anything).Return([]string{
+		"AppTests/LoginTests/testLogin()",
+		"AppTests/ProfileTests/testAvatar()",
+		"AppTests/SettingsTests/testTheme()",
+		"AppUITests/OnboardingTests/testSkip()",
+	}, nil)
+
+	config := Config{
+		TestRuns:    []*xctestrun.TestRun{testRun},
+		Destination: destination.Device{ID: "test-UDID"},
+		TestTimings: TestTimings{TestClasses: map[string]float64{
+			"AppUITests/OnboardingTests": 120,
+			"AppTests/LoginTests":        20,
+			"AppTests/ProfileTests":      10,
+		}},
+	}
+
+	// When
+	shard0, assignment, err := step.shardTests(config, 0, 2)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"AppUITests/OnboardingTests"}, shard0)
+	require.Equal(t, shardingStrategyTimings, assignment.Strategy)
+	require.Equal(t, []string{"AppTests/LoginTests", "AppTests/ProfileTests", "AppTests/SettingsTests"}, assignment.Shards[1].TestUnits)
+	require.Equal(t, 80.0, assignment.Shards[1].EstimatedDuration)
+}
+
+func Test_GivenEnumerationFails_WhenSharding_ThenFails(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests", "AppUITests"))
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("EnumerateTests", mock.Anything).Return(nil, errors.New("unknown option: -enumerate-tests"))
+
+	config := Config{
+		TestRuns:    []*xctestrun.TestRun{testRun},
+		Destination: destination.Device{ID: "test-UDID"},
+	}
+
+	// When
+	_, _, err = step.shardTests(config, 1, 2)
+
+	// Then
+	require.EqualError(t, err, "failed to enumerate the tests to split between the shards: unknown option: -enumerate-tests")
+}
+
+func Test_GivenMoreShardsThanTests_WhenSharding_ThenShardIsEmpty(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests"))
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("EnumerateTests", mock.Anything).Return([]string{"AppTests/LoginTests/testLogin()"}, nil)
+
+	// When
+	shard, assignment, err := step.shardTests(Config{TestRuns: []*xctestrun.TestRun{testRun}}, 1, 2)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, shard)
+	require.Equal(t, []string{"AppTests/LoginTests"}, assignment.Shards[0].TestUnits)
+	require.True(t, Config{ShardIndex: 1, ShardCount: 2, ShardAssignment: assignment}.isEmptyShard())
+	require.False(t, Config{ShardIndex: 0, ShardCount: 2, ShardAssignment: assignment}.isEmptyShard())
+}
+
+func Test_GivenEmptyShard_WhenStepRuns_ThenTestsSkippedAndSucceeded(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRun, err := xctestrun.Parse(writeXctestrun(t, "AppTests"))
+	require.NoError(t, err)
+
+	assignment := assignRoundRobin([]string{"AppTests/LoginTests"}, 2)
+	config := Config{
+		TestRuns:        []*xctestrun.TestRun{testRun},
+		ShardIndex:      1,
+		ShardCount:      2,
+		ShardAssignment: &assignment,
+	}
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+
+	// When
+	results, err := step.RunAll(config)
+	require.NoError(t, err)
+	err = step.ExportOutputs(config, results)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, results)
+	testingMocks.xcodebuild.AssertNotCalled(t, "TestWithoutBuilding", mock.Anything)
+	testingMocks.envRepository.AssertCalled(t, "Set", testResultKey, testResultSucceeded)
+}
+
+func Test_GivenShard_WhenStepRuns_ThenResultBundleNamedAfterShard(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	pth := writeXctestrun(t, "AppTests")
+	testRun, err := xctestrun.Parse(pth)
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.ResultBundleSuffix == "shard-1-of-2"
+	})).Return("", nil)
+
+	config := Config{
+		Xctestrun:   pth,
+		TestRun:     testRun,
+		Destination: destination.Device{ID: "test-UDID"},
+		OnlyTesting: []string{"AppTests/ProfileTests"},
+		ShardIndex:  1,
+		ShardCount:  2,
+	}
+
+	// When
+	_, err = step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	testingMocks.xcodebuild.AssertExpectations(t)
+}
@@ -0,0 +1,146 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+)
+
+type simulatorRun struct {
+	simulator destination.Device
+	outputDir string
+	attempts  []Attempt
+	err       error
+}
+
+// runOnSimulatorClones splits the tests between clones of the destination simulator and runs them concurrently.
+// Each clone runs its own xcodebuild process with its own log and result bundle, the result bundles are merged
+// into a single one. The clones are deleted once all the processes finished.
+func (s XcodebuildTester) runOnSimulatorClones(config Config, params xcodebuild.TestParams) (string, []Attempt, error) {
+	if config.TestRun == nil {
+		return s.runTests(config, params)
+	}
+
+	runConfig := config
+	runConfig.TestRuns = []*xctestrun.TestRun{config.TestRun}
+	assignment := assignTestUnits(s.testUnits(runConfig), config.SimulatorCount, config.TestTimings)
+
+	var groups [][]string
+	for _, shard := range assignment.Shards {
+		if len(shard.TestUnits) > 0 {
+			groups = append(groups, shard.TestUnits)
+		}
+	}
+	if len(groups) < 2 {
+		s.logger.Warnf("Not enough tests to split between %d simulators, running the tests on a single simulator", config.SimulatorCount)
+		return s.runTests(config, params)
+	}
+
+	var clones []destination.Device
+	defer func() {
+		for _, clone := range clones {
+			if err := s.simulatorManager.Delete(clone); err != nil {
+				s.logger.Warnf("Failed to delete simulator clone: %s", err)
+			}
+		}
+	}()
+
+	s.logger.Println()
+	s.logger.Infof("Running tests on %d simulators:", len(groups))
+	for i, group := range groups {
+		name := fmt.Sprintf("%s - Clone %d (%d)", config.Destination.Name, i+1, os.Getpid())
+		clone, err := s.simulatorManager.Clone(config.Destination, name)
+		if err != nil {
+			return "", nil, err
+		}
+		clones = append(clones, clone)
+
+		s.logger.Printf("- %s (%s): %s", clone.Name, clone.ID, strings.Join(group, ", "))
+	}
+
+	runs := make([]simulatorRun, len(groups))
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		cloneParams := params
+		cloneParams.Destination = clones[i]
+		cloneParams.OnlyTesting = narrowTestSelection(group, config.OnlyTesting)
+		cloneParams.ResultBundleSuffix = strings.TrimPrefix(params.ResultBundleSuffix+fmt.Sprintf("-simulator-%d", i+1), "-")
+
+		wg.Add(1)
+		go func(i int, cloneParams xcodebuild.TestParams) {
+			defer wg.Done()
+			outputDir, attempts, err := s.runTests(config, cloneParams)
+			for j := range attempts {
+				attempts[j].Simulator = cloneParams.Destination.Name
+			}
+			runs[i] = simulatorRun{simulator: cloneParams.Destination, outputDir: outputDir, attempts: attempts, err: err}
+		}(i, cloneParams)
+	}
+	wg.Wait()
+
+	return s.combineSimulatorRuns(runs, params)
+}
+
+// combineSimulatorRuns merges the result bundles of the concurrent runs and combines their attempts and errors.
+func (s XcodebuildTester) combineSimulatorRuns(runs []simulatorRun, params xcodebuild.TestParams) (string, []Attempt, error) {
+	var (
+		outputDirs []string
+		attempts   []Attempt
+		failed     []string
+		lastErr    error
+	)
+	for _, run := range runs {
+		attempts = append(attempts, run.attempts...)
+		if run.outputDir != "" {
+			outputDirs = append(outputDirs, run.outputDir)
+		}
+		if run.err != nil {
+			s.logger.Errorf("%s: %s", run.simulator.Name, run.err)
+			failed = append(failed, run.simulator.Name)
+			lastErr = run.err
+		}
+	}
+
+	outputDir, err := s.mergeResultBundles(outputDirs, params)
+	if err != nil {
+		if lastErr == nil {
+			return "", attempts, err
+		}
+		s.logger.Warnf("%s", err)
+	}
+
+	switch len(failed) {
+	case 0:
+		return outputDir, attempts, nil
+	case 1:
+		return outputDir, attempts, lastErr
+	default:
+		return outputDir, attempts, fmt.Errorf("tests failed on %d of %d simulators: %s", len(failed), len(runs), strings.Join(failed, ", "))
+	}
+}
+
+func (s XcodebuildTester) mergeResultBundles(outputDirs []string, params xcodebuild.TestParams) (string, error) {
+	switch len(outputDirs) {
+	case 0:
+		return "", nil
+	case 1:
+		return outputDirs[0], nil
+	}
+
+	tempDir, err := s.pathProvider.CreateTempDir("TestOutput")
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := filepath.Join(tempDir, xcodebuild.ResultBundleName(params))
+	if err := s.xcresultMerger.Merge(outputDirs, outputDir); err != nil {
+		return "", err
+	}
+	return outputDir, nil
+}
@@ -0,0 +1,105 @@
+package step
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenSimulatorCount_WhenStepRuns_ThenTestsSplitBetweenClones(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	pth := writeXctestrun(t, "AppTests", "AppUITests")
+	testRun, err := xctestrun.Parse(pth)
+	require.NoError(t, err)
+
+	device := destination.Device{ID: "test-UDID", Name: "iPhone 15"}
+	clone1 := destination.Device{ID: "clone-UDID-1", Name: "iPhone 15 - Clone 1"}
+	clone2 := destination.Device{ID: "clone-UDID-2", Name: "iPhone 15 - Clone 2"}
+
+	testingMocks.xcodebuild.On("EnumerateTests", mock.Anything).Return(nil, errors.New("unknown option: -enumerate-tests"))
+	testingMocks.simulatorManager.On("Clone", device, mock.Anything).Return(clone1, nil).Once()
+	testingMocks.simulatorManager.On("Clone", device, mock.Anything).Return(clone2, nil).Once()
+	testingMocks.simulatorManager.On("Delete", clone1).Return(nil).Once()
+	testingMocks.simulatorManager.On("Delete", clone2).Return(nil).Once()
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Destination == clone1 && params.ResultBundleSuffix == "simulator-1" && params.OnlyTesting[0] == "AppTests"
+	})).Return("Test-my_test-simulator-1.xcresult", nil).Once()
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Destination == clone2 && params.ResultBundleSuffix == "simulator-2" && params.OnlyTesting[0] == "AppUITests"
+	})).Return("Test-my_test-simulator-2.xcresult", errors.New("failing tests (exit status 65)")).Once()
+
+	testingMocks.xcresultMerger.On("Merge", []string{"Test-my_test-simulator-1.xcresult", "Test-my_test-simulator-2.xcresult"}, mock.MatchedBy(func(pth string) bool {
+		return strings.HasSuffix(pth, "/Test-my_test.xcresult")
+	})).Return(nil).Once()
+
+	config := Config{
+		Xctestrun:      pth,
+		TestRun:        testRun,
+		Destination:    device,
+		SimulatorCount: 2,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.EqualError(t, err, "failing tests (exit status 65)")
+	require.False(t, result.Succeeded)
+	require.Contains(t, result.TestOutputDir, "Test-my_test.xcresult")
+	require.Equal(t, []string{clone1.Name, clone2.Name}, []string{result.Attempts[0].Simulator, result.Attempts[1].Simulator})
+	testingMocks.simulatorManager.AssertExpectations(t)
+	testingMocks.xcodebuild.AssertExpectations(t)
+	testingMocks.xcresultMerger.AssertExpectations(t)
+}
+
+func Test_GivenSingleTestUnit_WhenRunningOnSimulatorClones_ThenSingleSimulatorUsed(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	pth := writeXctestrun(t, "AppTests")
+	testRun, err := xctestrun.Parse(pth)
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("EnumerateTests", mock.Anything).Return([]string{"AppTests/LoginTests/testLogin()"}, nil)
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Destination.ID == "test-UDID"
+	})).Return("Test-my_test.xcresult", nil).Once()
+
+	config := Config{
+		Xctestrun:      pth,
+		TestRun:        testRun,
+		Destination:    destination.Device{ID: "test-UDID"},
+		SimulatorCount: 3,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "Test-my_test.xcresult", result.TestOutputDir)
+	testingMocks.simulatorManager.AssertNotCalled(t, "Clone", mock.Anything, mock.Anything)
+}
+
+func Test_GivenMultipleFailingSimulators_WhenCombiningRuns_ThenErrorsCombined(t *testing.T) {
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.xcresultMerger.On("Merge", mock.Anything, mock.Anything).Return(nil)
+
+	_, _, err := step.combineSimulatorRuns([]simulatorRun{
+		{simulator: destination.Device{Name: "iPhone 15 - Clone 1"}, outputDir: "1.xcresult", err: errors.New("failing tests (exit status 65)")},
+		{simulator: destination.Device{Name: "iPhone 15 - Clone 2"}, outputDir: "2.xcresult"},
+		{simulator: destination.Device{Name: "iPhone 15 - Clone 3"}, err: errors.New("test execute failed")},
+	}, xcodebuild.TestParams{Xctestrun: "my_test.xctestrun"})
+
+	require.EqualError(t, err, "tests failed on 2 of 3 simulators: iPhone 15 - Clone 1, iPhone 15 - Clone 3")
+}
@@ -1,25 +1,58 @@
 package step
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-utils/v2/command"
 	"github.com/bitrise-io/go-utils/v2/env"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/simulator"
 	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
 	"github.com/kballard/go-shellquote"
 )
 
 const (
 	testResultBundleKey       = "BITRISE_XCRESULT_PATH"
 	zippedTestResultBundleKey = "BITRISE_XCRESULT_ZIP_PATH"
+	xctestrunKey              = "BITRISE_XCODE_TEST_XCTESTRUN_PATH"
+	testResultBundleListKey   = "BITRISE_XCRESULT_PATH_LIST"
+	zippedTestResultListKey   = "BITRISE_XCRESULT_ZIP_PATH_LIST"
+	testResultKey             = "BITRISE_XCODE_TEST_RESULT"
+	testAttemptsKey           = "BITRISE_XCODE_TEST_ATTEMPTS"
+	quarantinedFailuresKey    = "BITRISE_QUARANTINED_FAILURES"
+	flakyTestCountKey         = "BITRISE_FLAKY_TEST_COUNT"
+	flakyTestReportKey        = "BITRISE_FLAKY_TEST_REPORT_PATH"
+	junitReportKey            = "BITRISE_XCODE_TEST_JUNIT_PATH"
+	testSummaryKey            = "BITRISE_XCODE_TEST_SUMMARY_PATH"
+	passedTestCountKey        = "BITRISE_XCODE_TEST_PASSED_COUNT"
+	failedTestCountKey        = "BITRISE_XCODE_TEST_FAILED_COUNT"
+	skippedTestCountKey       = "BITRISE_XCODE_TEST_SKIPPED_COUNT"
+	expectedFailureCountKey   = "BITRISE_XCODE_TEST_EXPECTED_FAILURE_COUNT"
+	totalTestDurationKey      = "BITRISE_XCODE_TEST_TOTAL_DURATION"
+	attachmentsDirKey         = "BITRISE_XCODE_TEST_ATTACHMENTS_DIR"
+	codeCoverageKey           = "BITRISE_XCODE_TEST_CODE_COVERAGE"
+	targetCodeCoverageKey     = "BITRISE_XCODE_TEST_TARGET_CODE_COVERAGE"
+	codeCoverageLcovKey       = "BITRISE_XCODE_TEST_COVERAGE_LCOV_PATH"
+	codeCoverageCoberturaKey  = "BITRISE_XCODE_TEST_COVERAGE_COBERTURA_PATH"
+	codeCoverageSummaryKey    = "BITRISE_XCODE_TEST_COVERAGE_SUMMARY_PATH"
+	destinationResultsKey     = "BITRISE_XCODE_TEST_DESTINATION_RESULTS"
+	testTimingsKey            = "BITRISE_XCODE_TEST_TIMINGS_PATH"
+	shardAssignmentKey        = "BITRISE_XCODE_TEST_SHARD_ASSIGNMENT_PATH"
+)
+
+const (
+	testResultSucceeded = "succeeded"
+	testResultFailed    = "failed"
 )
 
 const (
@@ -50,25 +83,64 @@ var testRunnerErrorPatterns = []string{
 	failedToOpenTestRunner,
 }
 
+// simulatorErrorPatterns are the test runner errors caused by a broken simulator,
+// the simulator is reset before retrying the tests.
+var simulatorErrorPatterns = []string{
+	earlyUnexpectedExit,
+	failedToOpenTestRunner,
+}
+
 type Input struct {
-	Xctestrun         string `env:"xctestrun,required"`
-	Destination       string `env:"destination,required"`
-	XcodebuildOptions string `env:"xcodebuild_options"`
+	Xctestrun           string `env:"xctestrun"`
+	TestProductsArchive string `env:"test_products_archive"`
+	Destination         string `env:"destination,required"`
+	XcodebuildOptions   string `env:"xcodebuild_options"`
+
+	DestinationConcurrency int `env:"destination_concurrency"`
+
+	AutoSelectCompatibleRuntime bool `env:"auto_select_compatible_runtime,opt[yes,no]"`
+	SimulatorCount              int  `env:"simulator_count"`
 
-	TestRepetitionMode             string `env:"test_repetition_mode,opt[none,until_failure,retry_on_failure,up_until_maximum_repetitions]"`
+	TestRepetitionMode             string `env:"test_repetition_mode,opt[none,until_failure,retry_on_failure,up_until_maximum_repetitions,rerun_failed_tests]"`
 	MaximumTestRepetitions         int    `env:"maximum_test_repetitions,required"`
 	RelaunchTestsForEachRepetition bool   `env:"relaunch_tests_for_each_repetition,opt[yes,no]"`
 
 	DeployDir       string `env:"BITRISE_DEPLOY_DIR"`
 	TestingAddonDir string `env:"BITRISE_TEST_RESULT_DIR"`
 
-	OnlyTesting string `env:"only_testing"`
-	SkipTesting string `env:"skip_testing"`
+	OnlyTesting      string `env:"only_testing"`
+	SkipTesting      string `env:"skip_testing"`
+	QuarantinedTests string `env:"quarantined_tests"`
+
+	OnlyTestConfiguration string `env:"only_test_configuration"`
+	SkipTestConfiguration string `env:"skip_test_configuration"`
+
+	TestEnvironmentVariables string `env:"test_environment_variables"`
+	TestLaunchArguments      string `env:"test_launch_arguments"`
+
+	GenerateJUnitReport bool   `env:"generate_junit_report,opt[yes,no]"`
+	ExportAttachments   string `env:"export_attachments,opt[none,failures_only,all]"`
+	EnableCodeCoverage  bool   `env:"enable_code_coverage,opt[yes,no]"`
+
+	RetryPatternsFile   string `env:"retry_patterns_file"`
+	MaximumTestAttempts int    `env:"maximum_test_attempts"`
+	RetryDelay          int    `env:"retry_delay"`
+
+	ShardIndex      int    `env:"shard_index"`
+	ShardCount      int    `env:"shard_count"`
+	TestTimingsFile string `env:"test_timings_file"`
 }
 
 type Config struct {
+	// TestRuns are all the xctestrun files to test, Xctestrun and TestRun are set for a single test run (see RunAll).
+	// Destinations are all the simulators to test on, Destination is the simulator of a single test run.
+	TestRuns                       []*xctestrun.TestRun
 	Xctestrun                      string
+	TestRun                        *xctestrun.TestRun
+	Destinations                   []destination.Device
 	Destination                    destination.Device
+	DestinationConcurrency         int
+	SimulatorCount                 int
 	XcodebuildOptions              []string
 	TestRepetitionMode             string
 	MaximumTestRepetitions         int
@@ -77,41 +149,77 @@ type Config struct {
 	TestingAddonDir                string
 	OnlyTesting                    []string
 	SkipTesting                    []string
+	QuarantinedTests               []string
+	OnlyTestConfiguration          []string
+	SkipTestConfiguration          []string
+	TestEnvironmentVariables       map[string]string
+	TestLaunchArguments            []string
+	GenerateJUnitReport            bool
+	ExportAttachments              string
+	EnableCodeCoverage             bool
+	RetryPatterns                  []RetryPattern
+	MaximumTestAttempts            int
+	RetryDelay                     time.Duration
+	ShardIndex                     int
+	ShardCount                     int
+	TestTimings                    TestTimings
+	ShardAssignment                *ShardAssignment
 }
 
 type Result struct {
-	TestOutputDir   string
-	DeployDir       string
-	TestingAddonDir string
+	Xctestrun           string
+	Destination         destination.Device
+	Succeeded           bool
+	TestOutputDir       string
+	DeployDir           string
+	TestingAddonDir     string
+	RewrittenXctestrun  string
+	Attempts            []Attempt
+	QuarantinedFailures []string
 }
 
 type XcodebuildTester struct {
-	logger         log.Logger
-	inputParser    stepconf.InputParser
-	deviceFinder   destination.DeviceFinder
-	pathChecker    pathutil.PathChecker
-	xcodebuild     xcodebuild.Xcodebuild
-	outputEnvStore env.Repository
-	outputExporter OutputExporter
+	logger           log.Logger
+	inputParser      stepconf.InputParser
+	deviceFinder     destination.DeviceFinder
+	simulatorManager simulator.Manager
+	pathProvider     pathutil.PathProvider
+	pathChecker      pathutil.PathChecker
+	commandFactory   command.Factory
+	xcodebuild       xcodebuild.Xcodebuild
+	xcresultReader   xcresult.Reader
+	xcresultMerger   xcresult.Merger
+	outputEnvStore   env.Repository
+	outputExporter   OutputExporter
 }
 
 func NewXcodebuildTester(
 	logger log.Logger,
 	inputParser stepconf.InputParser,
 	deviceFinder destination.DeviceFinder,
+	simulatorManager simulator.Manager,
+	pathProvider pathutil.PathProvider,
 	pathChecker pathutil.PathChecker,
+	commandFactory command.Factory,
 	xcodebuild xcodebuild.Xcodebuild,
+	xcresultReader xcresult.Reader,
+	xcresultMerger xcresult.Merger,
 	outputEnvStore env.Repository,
 	outputExporter OutputExporter,
 ) XcodebuildTester {
 	return XcodebuildTester{
-		logger:         logger,
-		inputParser:    inputParser,
-		deviceFinder:   deviceFinder,
-		pathChecker:    pathChecker,
-		xcodebuild:     xcodebuild,
-		outputEnvStore: outputEnvStore,
-		outputExporter: outputExporter,
+		logger:           logger,
+		inputParser:      inputParser,
+		deviceFinder:     deviceFinder,
+		simulatorManager: simulatorManager,
+		pathProvider:     pathProvider,
+		pathChecker:      pathChecker,
+		commandFactory:   commandFactory,
+		xcodebuild:       xcodebuild,
+		xcresultReader:   xcresultReader,
+		xcresultMerger:   xcresultMerger,
+		outputEnvStore:   outputEnvStore,
+		outputExporter:   outputExporter,
 	}
 }
 
@@ -128,13 +236,69 @@ func (s XcodebuildTester) ProcessConfig() (*Config, error) {
 		return nil, fmt.Errorf("provided xcodebuild options (%s) are not valid CLI parameters: %w", input.XcodebuildOptions, err)
 	}
 
-	simulator, err := s.getSimulatorForDestination(input.Destination)
+	xctestrunInput := input.Xctestrun
+	if input.TestProductsArchive != "" {
+		if input.Xctestrun != "" {
+			s.logger.Warnf("The xctestrun input is ignored, the xctestrun files are searched in the test products archive")
+		}
+
+		xctestrunInput, err = s.extractTestProducts(input.TestProductsArchive)
+		if err != nil {
+			return nil, err
+		}
+	} else if input.Xctestrun == "" {
+		return nil, fmt.Errorf("either the xctestrun or the test_products_archive input is required")
+	}
+
+	if input.SimulatorCount < 0 {
+		return nil, fmt.Errorf("invalid simulator_count input (%d): it can't be negative", input.SimulatorCount)
+	}
+
+	if err := validateShard(input.ShardIndex, input.ShardCount); err != nil {
+		return nil, err
+	}
+
+	testRuns, err := s.parseTestRuns(xctestrunInput, input.TestProductsArchive != "")
 	if err != nil {
 		return nil, err
 	}
 
+	destinationSpecifiers := removeEmptyLines(strings.Split(input.Destination, "\n"))
+	if len(destinationSpecifiers) == 0 {
+		return nil, fmt.Errorf("destination input is empty")
+	}
+
+	var simulators []destination.Device
+	for _, destinationSpecifier := range destinationSpecifiers {
+		simulator, err := s.getSimulatorForDestination(destinationSpecifier)
+		if err != nil {
+			return nil, err
+		}
+
+		simulator, err = s.checkRuntimeCompatibility(testRuns, simulator, input.AutoSelectCompatibleRuntime)
+		if err != nil {
+			return nil, err
+		}
+
+		simulators = append(simulators, simulator)
+	}
+
 	s.logger.Infof("Simulator device:")
-	s.logger.Printf("- name: %s, version: %s, UDID: %s, status: %s", simulator.Name, simulator.OS, simulator.ID, simulator.Status)
+	for _, simulator := range simulators {
+		s.logger.Printf("- name: %s, version: %s, UDID: %s, status: %s", simulator.Name, simulator.OS, simulator.ID, simulator.Status)
+	}
+
+	if input.MaximumTestAttempts < 0 {
+		return nil, fmt.Errorf("invalid maximum_test_attempts input (%d): it can't be negative", input.MaximumTestAttempts)
+	}
+
+	if input.RetryDelay < 0 {
+		return nil, fmt.Errorf("invalid retry_delay input (%d): it can't be negative", input.RetryDelay)
+	}
+
+	if input.DestinationConcurrency < 0 {
+		return nil, fmt.Errorf("invalid destination_concurrency input (%d): it can't be negative", input.DestinationConcurrency)
+	}
 
 	onlyTesting, err := s.processTestConfiguration(input.OnlyTesting)
 	if err != nil {
@@ -146,9 +310,49 @@ func (s XcodebuildTester) ProcessConfig() (*Config, error) {
 		return nil, err
 	}
 
-	return &Config{
-		Xctestrun:                      input.Xctestrun,
-		Destination:                    simulator,
+	if err := s.validateTestSelection("only_testing", onlyTesting, testTargetNames(testRuns)); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTestSelection("skip_testing", skipTesting, testTargetNames(testRuns)); err != nil {
+		return nil, err
+	}
+
+	quarantinedTests, err := s.processTestConfiguration(input.QuarantinedTests)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTestSelection("quarantined_tests", quarantinedTests, testTargetNames(testRuns)); err != nil {
+		return nil, err
+	}
+
+	onlyTestConfiguration := removeEmptyLines(strings.Split(input.OnlyTestConfiguration, "\n"))
+	if err := validateTestConfigurations(onlyTestConfiguration, configurationNames(testRuns)); err != nil {
+		return nil, fmt.Errorf("invalid only_test_configuration input: %w", err)
+	}
+
+	skipTestConfiguration := removeEmptyLines(strings.Split(input.SkipTestConfiguration, "\n"))
+	if err := validateTestConfigurations(skipTestConfiguration, configurationNames(testRuns)); err != nil {
+		return nil, fmt.Errorf("invalid skip_test_configuration input: %w", err)
+	}
+
+	testEnvironmentVariables, err := parseTestEnvironmentVariables(input.TestEnvironmentVariables)
+	if err != nil {
+		return nil, fmt.Errorf("invalid test_environment_variables input: %w", err)
+	}
+
+	testLaunchArguments, err := shellquote.Split(input.TestLaunchArguments)
+	if err != nil {
+		return nil, fmt.Errorf("provided test launch arguments (%s) are not valid CLI parameters: %w", input.TestLaunchArguments, err)
+	}
+
+	config := &Config{
+		TestRuns:                       testRuns,
+		Destinations:                   simulators,
+		Destination:                    simulators[0],
+		DestinationConcurrency:         input.DestinationConcurrency,
+		SimulatorCount:                 input.SimulatorCount,
 		XcodebuildOptions:              xcodebuildOptions,
 		TestRepetitionMode:             input.TestRepetitionMode,
 		MaximumTestRepetitions:         input.MaximumTestRepetitions,
@@ -157,7 +361,43 @@ func (s XcodebuildTester) ProcessConfig() (*Config, error) {
 		TestingAddonDir:                input.TestingAddonDir,
 		OnlyTesting:                    onlyTesting,
 		SkipTesting:                    skipTesting,
-	}, nil
+		QuarantinedTests:               quarantinedTests,
+		OnlyTestConfiguration:          onlyTestConfiguration,
+		SkipTestConfiguration:          skipTestConfiguration,
+		TestEnvironmentVariables:       testEnvironmentVariables,
+		TestLaunchArguments:            testLaunchArguments,
+		GenerateJUnitReport:            input.GenerateJUnitReport,
+		ExportAttachments:              input.ExportAttachments,
+		EnableCodeCoverage:             input.EnableCodeCoverage,
+		MaximumTestAttempts:            input.MaximumTestAttempts,
+		RetryDelay:                     time.Duration(input.RetryDelay) * time.Second,
+	}
+
+	config.RetryPatterns = defaultRetryPatterns()
+	if input.RetryPatternsFile != "" {
+		config.RetryPatterns, err = readRetryPatterns(input.RetryPatternsFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry_patterns_file input: %w", err)
+		}
+	}
+
+	if input.TestTimingsFile != "" {
+		config.TestTimings, err = readTestTimings(input.TestTimingsFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid test_timings_file input: %w", err)
+		}
+	}
+
+	if input.ShardCount > 1 {
+		config.OnlyTesting, config.ShardAssignment, err = s.shardTests(*config, input.ShardIndex, input.ShardCount)
+		if err != nil {
+			return nil, err
+		}
+		config.ShardIndex = input.ShardIndex
+		config.ShardCount = input.ShardCount
+	}
+
+	return config, nil
 }
 
 func (s XcodebuildTester) Run(config Config) (*Result, error) {
@@ -165,36 +405,73 @@ func (s XcodebuildTester) Run(config Config) (*Result, error) {
 	s.logger.Infof("Running tests:")
 
 	result := &Result{
+		Xctestrun:       config.Xctestrun,
+		Destination:     config.Destination,
 		DeployDir:       config.DeployDir,
 		TestingAddonDir: config.TestingAddonDir,
 	}
 
-	runTests := func() (string, error) {
-		return s.xcodebuild.TestWithoutBuilding(
-			config.Xctestrun,
-			config.OnlyTesting,
-			config.SkipTesting,
-			config.Destination,
-			config.TestRepetitionMode,
-			config.MaximumTestRepetitions,
-			config.RelaunchTestsForEachRepetition,
-			config.XcodebuildOptions...)
+	if config.TestRun != nil {
+		if err := s.checkTestProducts(*config.TestRun); err != nil {
+			return result, err
+		}
 	}
 
-	outputDir, err := runTests()
+	xctestrunPath, rewritten, err := s.prepareXctestrun(config)
 	if err != nil {
-		var xcErr *xcodebuild.XcodebuildError
-		if errors.As(err, &xcErr) {
-			for _, errorPattern := range testRunnerErrorPatterns {
-				if isStringFoundInOutput(errorPattern, xcErr.Log) {
-					s.logger.Warnf("Automatic retry reason found in log: %s", errorPattern)
-					outputDir, err = runTests()
-				}
-			}
+		return result, err
+	}
+	if rewritten {
+		result.RewrittenXctestrun = xctestrunPath
+	}
+
+	// The rewritten xctestrun refers to the build products (Binaries/N) of the test products bundle, so it can be run on its own.
+	var testProductsPath, testPlan string
+	if !rewritten && config.TestRun != nil && config.TestRun.TestProductsPath != "" {
+		testProductsPath = config.TestRun.TestProductsPath
+		if config.TestRun.TestPlan != nil {
+			testPlan = config.TestRun.TestPlan.Name
 		}
 	}
 
+	params := xcodebuild.TestParams{
+		Xctestrun:                      xctestrunPath,
+		TestProductsPath:               testProductsPath,
+		TestPlan:                       testPlan,
+		OnlyTesting:                    config.OnlyTesting,
+		SkipTesting:                    config.SkipTesting,
+		OnlyTestConfiguration:          config.OnlyTestConfiguration,
+		SkipTestConfiguration:          config.SkipTestConfiguration,
+		Destination:                    config.Destination,
+		TestRepetitionMode:             config.TestRepetitionMode,
+		MaximumTestRepetitions:         config.MaximumTestRepetitions,
+		RelaunchTestsForEachRepetition: config.RelaunchTestsForEachRepetition,
+		ResultBundleSuffix:             testRunSuffix(config),
+		EnableCodeCoverage:             config.EnableCodeCoverage,
+		Options:                        config.XcodebuildOptions,
+	}
+	if config.TestRepetitionMode == testRepetitionRerunFailedTests {
+		// The failed tests are rerun by the step, not by xcodebuild.
+		params.TestRepetitionMode = xcodebuild.TestRepetitionNone
+		params.MaximumTestRepetitions = 0
+		params.RelaunchTestsForEachRepetition = false
+	}
+
+	var outputDir string
+	if config.SimulatorCount > 1 {
+		outputDir, result.Attempts, err = s.runOnSimulatorClones(config, params)
+	} else {
+		outputDir, result.Attempts, err = s.runTests(config, params)
+	}
+	if config.TestRepetitionMode == testRepetitionRerunFailedTests {
+		outputDir, result.Attempts, err = s.rerunFailedTests(config, params, outputDir, result.Attempts, err)
+	}
+	if err != nil && outputDir != "" && len(config.QuarantinedTests) > 0 {
+		result.QuarantinedFailures, err = s.checkQuarantinedFailures(config.QuarantinedTests, outputDir, err)
+	}
+
 	result.TestOutputDir = outputDir
+	result.Succeeded = err == nil
 
 	if err == nil {
 		s.logger.TDonef("Passing tests")
@@ -203,36 +480,203 @@ func (s XcodebuildTester) Run(config Config) (*Result, error) {
 	return result, err
 }
 
-func (s XcodebuildTester) ExportOutputs(result Result) error {
+func (s XcodebuildTester) ExportOutputs(config Config, results []Result) error {
 	s.logger.Println()
 	s.logger.Infof("Exporting outputs:")
 
-	if result.TestOutputDir != "" {
-		if err := s.outputEnvStore.Set(testResultBundleKey, result.TestOutputDir); err != nil {
-			s.logger.Warnf("Failed to export: %s: %s", testResultBundleKey, err)
-		} else {
-			s.logger.Donef("%s: %s", testResultBundleKey, result.TestOutputDir)
+	var (
+		rewrittenXctestruns []string
+		testOutputDirs      []string
+		zipPaths            []string
+		quarantinedFailures []string
+		testResult          = testResultSucceeded
+	)
+	for _, result := range results {
+		if !result.Succeeded {
+			testResult = testResultFailed
+		}
+
+		quarantinedFailures = append(quarantinedFailures, result.QuarantinedFailures...)
+
+		if result.RewrittenXctestrun != "" {
+			rewrittenXctestruns = append(rewrittenXctestruns, s.exportRewrittenXctestrun(result))
 		}
 
-		if result.DeployDir != "" {
-			xcresultZipPath := filepath.Join(result.DeployDir, filepath.Base(result.TestOutputDir)+".zip")
-			if err := s.outputExporter.ZipAndExportOutput(result.TestOutputDir, xcresultZipPath, zippedTestResultBundleKey); err != nil {
-				s.logger.Warnf("Failed to export: %s: %s", zippedTestResultBundleKey, err)
-			} else {
-				s.logger.Donef("%s: %s", zippedTestResultBundleKey, xcresultZipPath)
+		if result.TestOutputDir != "" {
+			testOutputDirs = append(testOutputDirs, result.TestOutputDir)
+			if zipPath := s.exportTestOutput(result); zipPath != "" {
+				zipPaths = append(zipPaths, zipPath)
 			}
 		}
+	}
 
-		if result.TestingAddonDir != "" {
-			testName := strings.TrimSuffix(filepath.Base(result.TestOutputDir), filepath.Ext(result.TestOutputDir))
+	if len(results) == 0 && !config.isEmptyShard() {
+		testResult = testResultFailed
+	}
+	s.exportOutput(testResultKey, testResult)
 
-			if err := s.outputExporter.CopyAndSaveTestData(result.TestOutputDir, result.TestingAddonDir, testName); err != nil {
-				s.logger.Warnf("Testing addon export failed: %s", err)
-			} else {
-				s.logger.Donef("Test result bundle moved to the testing addon dir: %s", result.TestingAddonDir)
-			}
+	if len(results) > 0 {
+		if content, err := json.Marshal(destinationResults(results)); err != nil {
+			s.logger.Warnf("Failed to export: %s: %s", destinationResultsKey, err)
+		} else {
+			s.exportOutput(destinationResultsKey, string(content))
+		}
+
+		if content, err := json.Marshal(testRunAttempts(results)); err != nil {
+			s.logger.Warnf("Failed to export: %s: %s", testAttemptsKey, err)
+		} else {
+			s.exportOutput(testAttemptsKey, string(content))
+		}
+	}
+
+	if len(rewrittenXctestruns) > 0 {
+		s.exportOutput(xctestrunKey, strings.Join(rewrittenXctestruns, "|"))
+	}
+	if len(testOutputDirs) > 0 {
+		s.exportOutput(testResultBundleListKey, strings.Join(testOutputDirs, "|"))
+	}
+	if len(zipPaths) > 0 {
+		s.exportOutput(zippedTestResultListKey, strings.Join(zipPaths, "|"))
+	}
+	if len(quarantinedFailures) > 0 {
+		s.exportOutput(quarantinedFailuresKey, strings.Join(quarantinedFailures, "\n"))
+	}
+
+	// The test reports are read once, for all the reports generated from them.
+	reports := s.readTestReports(results)
+	if len(testOutputDirs) > 0 {
+		s.exportTestCounts(results, reports)
+	}
+	if config.GenerateJUnitReport {
+		s.exportJUnitReports(results, reports)
+	}
+	if config.DeployDir != "" && len(results) > 0 {
+		s.exportTestSummary(config, results, reports)
+	}
+
+	if isExportingAttachments(config) {
+		s.exportAttachments(config, results)
+	}
+	if config.EnableCodeCoverage && len(testOutputDirs) > 0 {
+		s.exportCodeCoverage(config, results)
+	}
+
+	// Flaky tests are only detected if the tests can run more than once.
+	if isRepeatingTests(config) && len(reports) > 0 {
+		s.exportFlakyTestReport(config, results, reports)
+	}
+
+	// Test timings are exported for sharded runs, to balance the shards of the next build.
+	if config.DeployDir != "" && (config.ShardCount > 1 || len(config.TestTimings.TestClasses) > 0) {
+		s.exportTestTimings(config, results, reports)
+	}
+	if config.DeployDir != "" && config.ShardAssignment != nil {
+		pth := filepath.Join(config.DeployDir, shardAssignmentFileName)
+		if err := writeJSON(pth, config.ShardAssignment); err != nil {
+			s.logger.Warnf("Failed to export the test shard assignment: %s", err)
+		} else {
+			s.exportOutput(shardAssignmentKey, pth)
+		}
+	}
+
+	return nil
+}
+
+func (s XcodebuildTester) exportOutput(key, value string) {
+	if err := s.outputEnvStore.Set(key, value); err != nil {
+		s.logger.Warnf("Failed to export: %s: %s", key, err)
+	} else {
+		s.logger.Donef("%s: %s", key, value)
+	}
+}
+
+// exportTestOutput exports the test result bundle of a single test run, returns the path of the zipped bundle.
+func (s XcodebuildTester) exportTestOutput(result Result) string {
+	s.exportOutput(testResultBundleKey, result.TestOutputDir)
+
+	var xcresultZipPath string
+	if result.DeployDir != "" {
+		xcresultZipPath = filepath.Join(result.DeployDir, filepath.Base(result.TestOutputDir)+".zip")
+		if err := s.outputExporter.ZipAndExportOutput(result.TestOutputDir, xcresultZipPath, zippedTestResultBundleKey); err != nil {
+			s.logger.Warnf("Failed to export: %s: %s", zippedTestResultBundleKey, err)
+			xcresultZipPath = ""
+		} else {
+			s.logger.Donef("%s: %s", zippedTestResultBundleKey, xcresultZipPath)
+		}
+	}
+
+	if result.TestingAddonDir != "" {
+		testName := strings.TrimSuffix(filepath.Base(result.TestOutputDir), filepath.Ext(result.TestOutputDir))
+
+		if err := s.outputExporter.CopyAndSaveTestData(result.TestOutputDir, result.TestingAddonDir, testName); err != nil {
+			s.logger.Warnf("Testing addon export failed: %s", err)
+		} else {
+			s.logger.Donef("Test result bundle moved to the testing addon dir: %s", result.TestingAddonDir)
 		}
 	}
+
+	return xcresultZipPath
+}
+
+// exportRewrittenXctestrun copies the rewritten xctestrun to the deploy dir, returns its exported path.
+// testRunSuffix identifies the shard and the destination of the test run in the names of its output files.
+// The shard is part of the result bundle name, so the result bundles of the shards can be merged.
+func testRunSuffix(config Config) string {
+	var suffix string
+	if config.ShardCount > 1 {
+		suffix = fmt.Sprintf("shard-%d-of-%d", config.ShardIndex, config.ShardCount)
+	}
+	if len(config.Destinations) > 1 {
+		suffix = strings.TrimPrefix(suffix+"-"+destinationFileName(config.Destination), "-")
+	}
+	return suffix
+}
+
+func (s XcodebuildTester) exportRewrittenXctestrun(result Result) string {
+	pth := result.RewrittenXctestrun
+	if result.DeployDir != "" {
+		deployedPth := filepath.Join(result.DeployDir, filepath.Base(pth))
+		if err := s.outputExporter.CopyFile(pth, deployedPth); err != nil {
+			s.logger.Warnf("Failed to copy the xctestrun to the deploy dir: %s", err)
+		} else {
+			pth = deployedPth
+		}
+	}
+
+	return pth
+}
+
+func (s XcodebuildTester) printTestRun(testRun xctestrun.TestRun) {
+	s.logger.Println()
+	s.logger.Infof("Test run (format version %d):", testRun.FormatVersion)
+	if testRun.TestPlan != nil {
+		s.logger.Printf("- test plan: %s", testRun.TestPlan.Name)
+	}
+	if configurations := testRun.ConfigurationNames(); len(configurations) > 0 {
+		s.logger.Printf("- configurations: %s", strings.Join(configurations, ", "))
+	}
+	for _, target := range testRun.TestTargets() {
+		if target.IsUITestBundle {
+			s.logger.Printf("- test target: %s (UI tests)", target.BlueprintName)
+		} else {
+			s.logger.Printf("- test target: %s", target.BlueprintName)
+		}
+	}
+}
+
+func (s XcodebuildTester) validateTestSelection(inputKey string, identifiers []string, targetNames []string) error {
+	unverified, err := validateTestIdentifiers(identifiers, targetNames)
+	if err != nil {
+		return fmt.Errorf("invalid %s input: %w", inputKey, err)
+	}
+
+	if len(unverified) > 0 {
+		s.logger.Warnf("The test classes and methods of the following %s identifiers can't be verified, only their test targets were found in the xctestrun:", inputKey)
+		for _, identifier := range unverified {
+			s.logger.Warnf("- %s", identifier)
+		}
+	}
+
 	return nil
 }
 
@@ -274,14 +718,13 @@ func (s XcodebuildTester) processTestConfiguration(input string) ([]string, erro
 		contents = input
 	}
 
-	identifiers := strings.Split(contents, "\n")
-
-	return removeEmptyLines(identifiers), nil
-}
+	// The identifiers are trimmed, so the lists edited on Windows (CRLF line endings) or indented in the bitrise.yml work too.
+	var identifiers []string
+	for _, line := range removeEmptyLines(strings.Split(contents, "\n")) {
+		identifiers = append(identifiers, strings.TrimSpace(line))
+	}
 
-func isStringFoundInOutput(searchStr, outputToSearchIn string) bool {
-	r := regexp.MustCompile("(?i)" + searchStr)
-	return r.MatchString(outputToSearchIn)
+	return identifiers, nil
 }
 
 func removeEmptyLines(lines []string) []string {
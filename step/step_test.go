@@ -11,9 +11,12 @@ import (
 	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/v2/destination"
 	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks/xcodebuildmock"
 	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"howett.net/plist"
 )
 
 func Test_GivenStep_WhenProcessConfig_ThenSplitsAdditionalOptions(t *testing.T) {
@@ -36,7 +39,7 @@ func Test_GivenStep_WhenProcessConfig_ThenSplitsAdditionalOptions(t *testing.T)
 	require.NoError(t, err)
 
 	inputs := map[string]string{
-		"xctestrun":                          "my_test.xctestrun",
+		"xctestrun":                          writeXctestrun(t, "target1", "target2", "target3", "target4", "target5", "target6"),
 		"destination":                        "platform=iOS Simulator,name=iPhone 8 Plus,OS=latest",
 		"test_repetition_mode":               "none",
 		"maximum_test_repetitions":           "3",
@@ -49,6 +52,7 @@ func Test_GivenStep_WhenProcessConfig_ThenSplitsAdditionalOptions(t *testing.T)
 		testingMocks.envRepository.On("Get", key).Return(value)
 	}
 
+	stubDefaultInputs(testingMocks.envRepository)
 	testingMocks.envRepository.On("Get", mock.Anything).Return("")
 	testingMocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(destination.Device{
 		ID: "test-UDID",
@@ -64,11 +68,99 @@ func Test_GivenStep_WhenProcessConfig_ThenSplitsAdditionalOptions(t *testing.T)
 	require.Equal(t, skipTesting, config.SkipTesting)
 }
 
+func Test_GivenStep_WhenProcessConfig_ThenParsesAutoSelectCompatibleRuntime(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.envRepository.On("Get", "xctestrun").Return(parseXctestrunWithMinimumOSVersion(t, "16.0").Path)
+	testingMocks.envRepository.On("Get", "destination").Return("platform=iOS Simulator,name=iPhone 8 Plus,OS=15.5")
+	testingMocks.envRepository.On("Get", "auto_select_compatible_runtime").Return("yes")
+	stubDefaultInputs(testingMocks.envRepository)
+	testingMocks.envRepository.On("Get", mock.Anything).Return("")
+
+	testingMocks.deviceFinder.On("FindDevice", destination.Simulator{Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "15.5"}).Return(destination.Device{
+		ID: "test-UDID", Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "15.5",
+	}, nil)
+	testingMocks.simulatorManager.On("ListRuntimes").Return(testRuntimes, nil)
+	compatibleDevice := destination.Device{ID: "compatible-UDID", Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "16.4"}
+	testingMocks.deviceFinder.On("FindDevice", destination.Simulator{Platform: "iOS Simulator", Name: "iPhone 8 Plus", OS: "16.4"}).Return(compatibleDevice, nil)
+
+	// When
+	config, err := step.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, compatibleDevice, config.Destination)
+}
+
+func Test_GivenStep_WhenProcessConfig_ThenParsesGenerateJUnitReport(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.envRepository.On("Get", "xctestrun").Return(writeXctestrun(t, "AppTests"))
+	testingMocks.envRepository.On("Get", "destination").Return("platform=iOS Simulator,name=iPhone 8 Plus,OS=latest")
+	testingMocks.envRepository.On("Get", "generate_junit_report").Return("yes")
+	stubDefaultInputs(testingMocks.envRepository)
+	testingMocks.envRepository.On("Get", mock.Anything).Return("")
+	testingMocks.deviceFinder.On("FindDevice", mock.Anything).Return(destination.Device{
+		ID: "test-UDID",
+	}, nil)
+
+	// When
+	config, err := step.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, config.GenerateJUnitReport)
+}
+
+func Test_GivenStep_WhenProcessConfig_ThenParsesExportAttachments(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.envRepository.On("Get", "xctestrun").Return(writeXctestrun(t, "AppTests"))
+	testingMocks.envRepository.On("Get", "destination").Return("platform=iOS Simulator,name=iPhone 8 Plus,OS=latest")
+	testingMocks.envRepository.On("Get", "export_attachments").Return("failures_only")
+	stubDefaultInputs(testingMocks.envRepository)
+	testingMocks.envRepository.On("Get", mock.Anything).Return("")
+	testingMocks.deviceFinder.On("FindDevice", mock.Anything).Return(destination.Device{
+		ID: "test-UDID",
+	}, nil)
+
+	// When
+	config, err := step.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "failures_only", config.ExportAttachments)
+}
+
+func Test_GivenStep_WhenProcessConfig_ThenParsesEnableCodeCoverage(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.envRepository.On("Get", "xctestrun").Return(writeXctestrun(t, "AppTests"))
+	testingMocks.envRepository.On("Get", "destination").Return("platform=iOS Simulator,name=iPhone 8 Plus,OS=latest")
+	testingMocks.envRepository.On("Get", "enable_code_coverage").Return("yes")
+	stubDefaultInputs(testingMocks.envRepository)
+	testingMocks.envRepository.On("Get", mock.Anything).Return("")
+	testingMocks.deviceFinder.On("FindDevice", mock.Anything).Return(destination.Device{
+		ID: "test-UDID",
+	}, nil)
+
+	// When
+	config, err := step.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, config.EnableCodeCoverage)
+}
+
 func Test_GivenStep_WhenXcodebuildFailsOnAutomaticRetryReason_ThenXcodebuildCommandRetried(t *testing.T) {
 	// Given
 	step, testingMocks := createStepAndMocks(t)
 
-	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("", &xcodebuild.XcodebuildError{Log: "Test runner never began executing tests after launching."})
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("", &xcodebuild.XcodebuildError{Log: "Test runner never began executing tests after launching."})
 	testingMocks.logger.On("Println").Return()
 	testingMocks.logger.On("Infof", mock.Anything).Return()
 	testingMocks.logger.On("Warnf", mock.Anything, mock.Anything).Return()
@@ -100,6 +192,7 @@ func Test_GivenDeployDir_WhenStepExportsOutputs_ThenTestResultMovedToDeployDir(t
 	testingMocks.logger.On("Infof", mock.Anything).Return()
 	testingMocks.logger.On("Donef", mock.Anything, mock.Anything, mock.Anything).Return()
 	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.xcresultReader.On("ReadTestResults", mock.Anything).Return(&xcresult.TestResults{}, nil)
 
 	result := Result{
 		TestOutputDir: "my_test.xcresult",
@@ -109,7 +202,7 @@ func Test_GivenDeployDir_WhenStepExportsOutputs_ThenTestResultMovedToDeployDir(t
 	testingMocks.outputExporter.On("ZipAndExportOutput", result.TestOutputDir, mock.Anything, mock.Anything).Return(nil)
 
 	// When
-	err := step.ExportOutputs(result)
+	err := step.ExportOutputs(Config{}, []Result{result})
 
 	// Then
 	require.NoError(t, err)
@@ -124,6 +217,7 @@ func Test_GivenTestingAddonDir_WhenStepExportsOutputs_ThenTestResultMovedToTesti
 	testingMocks.logger.On("Infof", mock.Anything).Return()
 	testingMocks.logger.On("Donef", mock.Anything, mock.Anything, mock.Anything).Return()
 	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.xcresultReader.On("ReadTestResults", mock.Anything).Return(&xcresult.TestResults{}, nil)
 
 	result := Result{
 		TestOutputDir:   "my_test.xcresult",
@@ -133,20 +227,70 @@ func Test_GivenTestingAddonDir_WhenStepExportsOutputs_ThenTestResultMovedToTesti
 	testingMocks.outputExporter.On("CopyAndSaveTestData", result.TestOutputDir, mock.Anything, mock.Anything).Return(nil)
 
 	// When
-	err := step.ExportOutputs(result)
+	err := step.ExportOutputs(Config{}, []Result{result})
 
 	// Then
 	require.NoError(t, err)
 	testingMocks.outputExporter.AssertExpectations(t)
 }
 
+func writeXctestrun(t *testing.T, targetNames ...string) string {
+	return writeXctestrunTo(t, t.TempDir(), "my_test.xctestrun", targetNames...)
+}
+
+func writeXctestrunTo(t *testing.T, dir, name string, targetNames ...string) string {
+	content := map[string]interface{}{
+		"__xctestrun_metadata__": map[string]interface{}{"FormatVersion": 1},
+	}
+	for _, name := range targetNames {
+		content[name] = map[string]interface{}{
+			"BlueprintName":  name,
+			"TestBundlePath": "__TESTHOST__/PlugIns/" + name + ".xctest",
+			"TestHostPath":   "__TESTROOT__/Debug-iphonesimulator/App.app",
+		}
+	}
+
+	bytes, err := plist.MarshalIndent(content, plist.XMLFormat, "\t")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, bytes, 0644))
+
+	for _, name := range targetNames {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "Debug-iphonesimulator", "App.app", "PlugIns", name+".xctest"), 0755))
+	}
+
+	return path
+}
+
 type testingMocks struct {
-	envRepository  *mocks.Repository
-	inputParser    stepconf.InputParser
-	logger         *mocks.Logger
-	deviceFinder   *mocks.DeviceFinder
-	xcodebuild     *mocks.Xcodebuild
-	outputExporter *mocks.OutputExporter
+	envRepository    *mocks.Repository
+	inputParser      stepconf.InputParser
+	logger           *mocks.Logger
+	deviceFinder     *mocks.DeviceFinder
+	simulatorManager *mocks.Manager
+	xcodebuild       *xcodebuildmock.Xcodebuild
+	xcresultReader   *mocks.Reader
+	xcresultMerger   *mocks.Merger
+	outputExporter   *mocks.OutputExporter
+	commandFactory   *mocks.Factory
+}
+
+// stubDefaultInputs stubs the default values of the inputs which can't be empty,
+// the inputs stubbed before keep their values.
+func stubDefaultInputs(envRepository *mocks.Repository) {
+	defaults := map[string]string{
+		"test_repetition_mode":               "none",
+		"maximum_test_repetitions":           "3",
+		"relaunch_tests_for_each_repetition": "no",
+		"auto_select_compatible_runtime":     "no",
+		"generate_junit_report":              "no",
+		"export_attachments":                 "none",
+		"enable_code_coverage":               "no",
+	}
+	for key, value := range defaults {
+		envRepository.On("Get", key).Return(value)
+	}
 }
 
 func createStepAndMocks(t *testing.T) (XcodebuildTester, testingMocks) {
@@ -154,18 +298,27 @@ func createStepAndMocks(t *testing.T) (XcodebuildTester, testingMocks) {
 	inputParser := stepconf.NewInputParser(envRepository)
 	logger := new(mocks.Logger)
 	deviceFinder := mocks.NewDeviceFinder(t)
-	xcbuild := new(mocks.Xcodebuild)
+	xcbuild := new(xcodebuildmock.Xcodebuild)
+	xcresultReader := new(mocks.Reader)
+	xcresultMerger := new(mocks.Merger)
+	simulatorManager := new(mocks.Manager)
 	outputExporter := new(mocks.OutputExporter)
+	commandFactory := new(mocks.Factory)
+	pathProvider := pathutil.NewPathProvider()
 	pathChecker := pathutil.NewPathChecker()
-	step := NewXcodebuildTester(log.NewLogger(), inputParser, deviceFinder, pathChecker, xcbuild, envRepository, outputExporter)
+	step := NewXcodebuildTester(log.NewLogger(), inputParser, deviceFinder, simulatorManager, pathProvider, pathChecker, commandFactory, xcbuild, xcresultReader, xcresultMerger, envRepository, outputExporter)
 
 	m := testingMocks{
-		envRepository:  envRepository,
-		inputParser:    inputParser,
-		logger:         logger,
-		deviceFinder:   deviceFinder,
-		xcodebuild:     xcbuild,
-		outputExporter: outputExporter,
+		envRepository:    envRepository,
+		inputParser:      inputParser,
+		logger:           logger,
+		deviceFinder:     deviceFinder,
+		simulatorManager: simulatorManager,
+		xcodebuild:       xcbuild,
+		xcresultReader:   xcresultReader,
+		xcresultMerger:   xcresultMerger,
+		outputExporter:   outputExporter,
+		commandFactory:   commandFactory,
 	}
 
 	return step, m
@@ -0,0 +1,78 @@
+package step
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+// readTestReports reads the test reports of the test result bundles by their paths,
+// the bundles which can't be read are left out.
+func (s XcodebuildTester) readTestReports(results []Result) map[string]*xcresult.TestResults {
+	reports := map[string]*xcresult.TestResults{}
+	for _, result := range results {
+		if result.TestOutputDir == "" {
+			continue
+		}
+		if _, ok := reports[result.TestOutputDir]; ok {
+			continue
+		}
+
+		testResults, err := s.xcresultReader.ReadTestResults(result.TestOutputDir)
+		if err != nil {
+			s.logger.Warnf("Failed to read the test results of %s: %s", result.TestOutputDir, err)
+			continue
+		}
+		reports[result.TestOutputDir] = testResults
+	}
+	return reports
+}
+
+// totalSummary sums the summaries of the test reports.
+func totalSummary(reports []*xcresult.TestResults) xcresult.Summary {
+	var total xcresult.Summary
+	for _, testResults := range reports {
+		summary := testResults.Summary()
+		total.TotalTestCount += summary.TotalTestCount
+		total.PassedTests += summary.PassedTests
+		total.FailedTests += summary.FailedTests
+		total.SkippedTests += summary.SkippedTests
+		total.ExpectedFailures += summary.ExpectedFailures
+		total.Duration += summary.Duration
+	}
+	return total
+}
+
+// testReportsOf returns the test reports of the test runs, in the order of the test runs.
+func testReportsOf(results []Result, reports map[string]*xcresult.TestResults) []*xcresult.TestResults {
+	var testResults []*xcresult.TestResults
+	for _, result := range results {
+		if report, ok := reports[result.TestOutputDir]; ok {
+			testResults = append(testResults, report)
+		}
+	}
+	return testResults
+}
+
+// exportTestCounts exports the number of tests by their results and the total test duration (in seconds).
+// The counts are only exported if the test reports of all the test runs could be read, partial counts are not exported.
+func (s XcodebuildTester) exportTestCounts(results []Result, reports map[string]*xcresult.TestResults) {
+	for _, result := range results {
+		if result.TestOutputDir == "" {
+			continue
+		}
+		if _, ok := reports[result.TestOutputDir]; !ok {
+			s.logger.Warnf("The test counts are not exported, the test results of %s are not available", result.TestOutputDir)
+			return
+		}
+	}
+
+	total := totalSummary(testReportsOf(results, reports))
+
+	s.exportOutput(passedTestCountKey, strconv.Itoa(total.PassedTests))
+	s.exportOutput(failedTestCountKey, strconv.Itoa(total.FailedTests))
+	s.exportOutput(skippedTestCountKey, strconv.Itoa(total.SkippedTests))
+	s.exportOutput(expectedFailureCountKey, strconv.Itoa(total.ExpectedFailures))
+	s.exportOutput(totalTestDurationKey, fmt.Sprintf("%.3f", total.Duration.Seconds()))
+}
@@ -0,0 +1,77 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenTestResults_WhenStepExportsOutputs_ThenTestCountsExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-App.xcresult").Return(&xcresult.TestResults{TestNodes: []xcresult.TestNode{{
+		NodeType: "Unit test bundle",
+		Name:     "AppTests",
+		Children: []xcresult.TestNode{{
+			NodeType: "Test Suite",
+			Name:     "LoginTests",
+			Children: []xcresult.TestNode{
+				{NodeType: "Test Case", Name: "testLogin()", Result: xcresult.TestResultPassed, DurationInSeconds: 1.5},
+				{NodeType: "Test Case", Name: "testLogout()", Result: xcresult.TestResultFailed, DurationInSeconds: 2},
+				{NodeType: "Test Case", Name: "testSignUp()", Result: xcresult.TestResultSkipped},
+			},
+		}},
+	}}}, nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-Widget.xcresult").Return(testResultsOf(map[string]string{
+		"WidgetTests/TimelineTests/testEntries()": xcresult.TestResultExpectedFailure,
+		"WidgetTests/TimelineTests/testReload()":  xcresult.TestResultPassed,
+	}), nil)
+
+	results := []Result{
+		{TestOutputDir: "/tmp/Test-App.xcresult", DeployDir: t.TempDir()},
+		{TestOutputDir: "/tmp/Test-Widget.xcresult", DeployDir: t.TempDir()},
+	}
+
+	// When
+	err := step.ExportOutputs(Config{}, results)
+
+	// Then
+	require.NoError(t, err)
+	testingMocks.envRepository.AssertCalled(t, "Set", passedTestCountKey, "2")
+	testingMocks.envRepository.AssertCalled(t, "Set", failedTestCountKey, "1")
+	testingMocks.envRepository.AssertCalled(t, "Set", skippedTestCountKey, "1")
+	testingMocks.envRepository.AssertCalled(t, "Set", expectedFailureCountKey, "1")
+	testingMocks.envRepository.AssertCalled(t, "Set", totalTestDurationKey, "3.500")
+}
+
+func Test_GivenUnreadableTestResults_WhenStepExportsOutputs_ThenTestCountsNotExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()": xcresult.TestResultPassed,
+	}), nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "/tmp/Test-Broken.xcresult").Return(nil, errors.New("corrupt bundle"))
+
+	results := []Result{
+		{TestOutputDir: "/tmp/Test-App.xcresult", DeployDir: t.TempDir()},
+		{TestOutputDir: "/tmp/Test-Broken.xcresult", DeployDir: t.TempDir()},
+	}
+
+	// When
+	err := step.ExportOutputs(Config{}, results)
+
+	// Then
+	require.NoError(t, err)
+	for _, key := range []string{passedTestCountKey, failedTestCountKey, skippedTestCountKey, expectedFailureCountKey, totalTestDurationKey} {
+		testingMocks.envRepository.AssertNotCalled(t, "Set", key, mock.Anything)
+	}
+}
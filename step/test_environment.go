@@ -0,0 +1,58 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+)
+
+// parseTestEnvironmentVariables parses newline separated KEY=VALUE pairs.
+func parseTestEnvironmentVariables(input string) (map[string]string, error) {
+	envs := map[string]string{}
+	for _, line := range removeEmptyLines(strings.Split(input, "\n")) {
+		components := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(components[0])
+		if len(components) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid environment variable (%s): expected format is KEY=VALUE", line)
+		}
+		envs[key] = components[1]
+	}
+
+	if len(envs) == 0 {
+		return nil, nil
+	}
+	return envs, nil
+}
+
+// prepareXctestrun returns the xctestrun to pass to xcodebuild. When test environment variables
+// or launch arguments are configured, a temporary copy of the xctestrun is written with those values injected.
+func (s XcodebuildTester) prepareXctestrun(config Config) (string, bool, error) {
+	if len(config.TestEnvironmentVariables) == 0 && len(config.TestLaunchArguments) == 0 {
+		return config.Xctestrun, false, nil
+	}
+
+	tempDir, err := s.pathProvider.CreateTempDir("xctestrun")
+	if err != nil {
+		return "", false, err
+	}
+
+	// The rewritten xctestrun is exported to the deploy dir, so the runs on other destinations and shards can't overwrite it.
+	name := filepath.Base(config.TestRun.Path)
+	if suffix := testRunSuffix(config); suffix != "" {
+		name = strings.TrimSuffix(name, xctestrun.Ext) + "-" + suffix + xctestrun.Ext
+	}
+
+	pth := filepath.Join(tempDir, name)
+	if err := config.TestRun.WriteWithTestEnvironment(pth, xctestrun.TestEnvironment{
+		EnvironmentVariables: config.TestEnvironmentVariables,
+		LaunchArguments:      config.TestLaunchArguments,
+	}); err != nil {
+		return "", false, fmt.Errorf("failed to inject test environment into the xctestrun: %w", err)
+	}
+
+	s.logger.Printf("Test environment injected into the xctestrun: %s", pth)
+
+	return pth, true, nil
+}
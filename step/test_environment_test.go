@@ -0,0 +1,129 @@
+package step
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTestEnvironmentVariables(t *testing.T) {
+	envs, err := parseTestEnvironmentVariables("API_BASE_URL=https://staging.example.com\n\nQUERY=a=b\nEMPTY=")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"API_BASE_URL": "https://staging.example.com", "QUERY": "a=b", "EMPTY": ""}, envs)
+
+	envs, err = parseTestEnvironmentVariables("")
+	require.NoError(t, err)
+	require.Nil(t, envs)
+
+	_, err = parseTestEnvironmentVariables("API_BASE_URL")
+	require.EqualError(t, err, "invalid environment variable (API_BASE_URL): expected format is KEY=VALUE")
+}
+
+func Test_GivenTestEnvironment_WhenStepRuns_ThenRewrittenXctestrunUsed(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	originalPth := writeXctestrun(t, "target1")
+	testRun, err := xctestrun.Parse(originalPth)
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Xctestrun != originalPth
+	})).Return("", nil)
+
+	config := Config{
+		Xctestrun:                originalPth,
+		TestRun:                  testRun,
+		Destination:              destination.Device{ID: "test-UDID"},
+		TestEnvironmentVariables: map[string]string{"API_BASE_URL": "https://staging.example.com"},
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	require.NotEmpty(t, result.RewrittenXctestrun)
+
+	rewritten, err := xctestrun.Parse(result.RewrittenXctestrun)
+	require.NoError(t, err)
+	require.Equal(t, "https://staging.example.com", rewritten.TestTargets()[0].EnvironmentVariables["API_BASE_URL"])
+	testingMocks.xcodebuild.AssertExpectations(t)
+}
+
+func Test_GivenTestEnvironmentOnMultipleDestinations_WhenStepRuns_ThenRewrittenXctestrunsNamedAfterShardAndDestination(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	originalPth := writeXctestrun(t, "target1")
+	testRun, err := xctestrun.Parse(originalPth)
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.Anything).Return("", nil)
+
+	iPhone := destination.Device{ID: "iphone-UDID", Name: "iPhone 15", OS: "17.5"}
+	iPad := destination.Device{ID: "ipad-UDID", Name: "iPad Air", OS: "17.5"}
+	config := Config{
+		Xctestrun:                originalPth,
+		TestRun:                  testRun,
+		Destinations:             []destination.Device{iPhone, iPad},
+		ShardIndex:               1,
+		ShardCount:               2,
+		TestEnvironmentVariables: map[string]string{"API_BASE_URL": "https://staging.example.com"},
+	}
+
+	// When
+	var rewritten []string
+	for _, device := range config.Destinations {
+		config.Destination = device
+		result, err := step.Run(config)
+		require.NoError(t, err)
+		rewritten = append(rewritten, filepath.Base(result.RewrittenXctestrun))
+	}
+
+	// Then
+	require.Equal(t, []string{
+		"my_test-shard-1-of-2-iPhone-15-17.5.xctestrun",
+		"my_test-shard-1-of-2-iPad-Air-17.5.xctestrun",
+	}, rewritten)
+}
+
+func Test_GivenTestProductsBundleWithTestEnvironment_WhenStepRuns_ThenRewrittenXctestrunRefersToBundleBinaries(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRuns, err := xctestrun.ParseTestProducts(filepath.Join("..", "xctestrun", "testdata", "BullsEye.xctestproducts"))
+	require.NoError(t, err)
+	testRun := testRuns[0]
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.TestProductsPath == "" && params.Xctestrun != testRun.Path
+	})).Return("", nil)
+
+	config := Config{
+		Xctestrun:                testRun.Path,
+		TestRun:                  testRun,
+		Destination:              destination.Device{ID: "test-UDID"},
+		TestLaunchArguments:      []string{"-FeatureFlags", "all"},
+		TestEnvironmentVariables: map[string]string{"API_BASE_URL": "https://staging.example.com"},
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+
+	rewritten, err := xctestrun.Parse(result.RewrittenXctestrun)
+	require.NoError(t, err)
+	for _, target := range rewritten.TestTargets() {
+		require.Equal(t, "https://staging.example.com", target.EnvironmentVariables["API_BASE_URL"])
+		require.DirExists(t, target.TestHostPath)
+	}
+	testingMocks.xcodebuild.AssertExpectations(t)
+}
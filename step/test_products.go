@@ -0,0 +1,81 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+)
+
+// extractTestProducts extracts the zip or tar.gz test products archive into a temporary directory.
+// Returns the test products bundle of the archive, or the extracted directory if it contains xctestrun files.
+func (s XcodebuildTester) extractTestProducts(archivePth string) (string, error) {
+	var (
+		name string
+		args []string
+	)
+
+	dir, err := s.pathProvider.CreateTempDir("TestProducts")
+	if err != nil {
+		return "", err
+	}
+
+	lowercasedPth := strings.ToLower(archivePth)
+	switch {
+	case strings.HasSuffix(lowercasedPth, ".zip"):
+		name, args = "unzip", []string{"-q", archivePth, "-d", dir}
+	case strings.HasSuffix(lowercasedPth, ".tar.gz"), strings.HasSuffix(lowercasedPth, ".tgz"):
+		name, args = "tar", []string{"-xzf", archivePth, "-C", dir}
+	default:
+		return "", fmt.Errorf("unsupported test products archive (%s): expected a .zip or .tar.gz file", archivePth)
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Extracting test products archive:")
+
+	cmd := s.commandFactory.Create(name, args, nil)
+	s.logger.TDonef("$ %s", cmd.PrintableCommandArgs())
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to extract test products archive: %w, output: %s", err, out)
+	}
+
+	return testProductsOf(archivePth, dir)
+}
+
+// testProductsOf returns the test products bundle in the extracted archive dir,
+// or the dir itself if it contains loose xctestrun files.
+func testProductsOf(archivePth, dir string) (string, error) {
+	var bundles []string
+	err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && filepath.Ext(pth) == xctestrun.TestProductsExt {
+			bundles = append(bundles, pth)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search the extracted test products archive: %w", err)
+	}
+
+	switch len(bundles) {
+	case 0:
+	case 1:
+		return bundles[0], nil
+	default:
+		return "", fmt.Errorf("invalid test products archive (%s): it contains multiple %s bundles", archivePth, xctestrun.TestProductsExt)
+	}
+
+	xctestrunPths, err := xctestrun.Find(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(xctestrunPths) == 0 {
+		return "", fmt.Errorf("invalid test products archive (%s): it contains no %s bundle or xctestrun file", archivePth, xctestrun.TestProductsExt)
+	}
+	return dir, nil
+}
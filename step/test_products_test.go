@@ -0,0 +1,129 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenTestProductsArchive_WhenExtracted_ThenTestProductsFound(t *testing.T) {
+	xctestrunContent, err := os.ReadFile(writeXctestrun(t, "AppTests"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		archivePth  string
+		command     string
+		args        func(dir string) []string
+		files       map[string][]byte
+		wantProduct string
+	}{
+		{
+			name:       "zip with xctestrun",
+			archivePth: "/archives/TestProducts.zip",
+			command:    "unzip",
+			args:       func(dir string) []string { return []string{"-q", "/archives/TestProducts.zip", "-d", dir} },
+			files: map[string][]byte{
+				"Build/Products/App_iphonesimulator17.5-arm64.xctestrun": xctestrunContent,
+				"Build/Products/Debug-iphonesimulator/App.app/App":       []byte("binary"),
+			},
+		},
+		{
+			name:       "tar.gz with xctestrun",
+			archivePth: "/archives/TestProducts.tar.gz",
+			command:    "tar",
+			args:       func(dir string) []string { return []string{"-xzf", "/archives/TestProducts.tar.gz", "-C", dir} },
+			files: map[string][]byte{
+				"Build/Products/App_iphonesimulator17.5-arm64.xctestrun": xctestrunContent,
+			},
+		},
+		{
+			name:       "zip with test products bundle",
+			archivePth: "/archives/App.xctestproducts.zip",
+			command:    "unzip",
+			args:       func(dir string) []string { return []string{"-q", "/archives/App.xctestproducts.zip", "-d", dir} },
+			files: map[string][]byte{
+				"App.xctestproducts/Info.plist":                                      []byte("<plist/>"),
+				"App.xctestproducts/Tests/0/App_iphonesimulator17.5-arm64.xctestrun": xctestrunContent,
+			},
+			wantProduct: "App.xctestproducts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, testingMocks := createStepAndMocks(t)
+			dir := expectArchiveExtraction(t, testingMocks.commandFactory, tt.command, tt.args, tt.files, nil)
+
+			pth, err := step.extractTestProducts(tt.archivePth)
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(*dir, tt.wantProduct), pth)
+			testingMocks.commandFactory.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_GivenArchiveWithoutTestProducts_WhenExtracted_ThenFails(t *testing.T) {
+	step, testingMocks := createStepAndMocks(t)
+	expectArchiveExtraction(t, testingMocks.commandFactory, "unzip", func(dir string) []string {
+		return []string{"-q", "TestProducts.zip", "-d", dir}
+	}, map[string][]byte{"README.md": []byte("no test products")}, nil)
+
+	_, err := step.extractTestProducts("TestProducts.zip")
+	require.EqualError(t, err, "invalid test products archive (TestProducts.zip): it contains no .xctestproducts bundle or xctestrun file")
+}
+
+func Test_GivenCorruptArchive_WhenExtracted_ThenFails(t *testing.T) {
+	step, testingMocks := createStepAndMocks(t)
+	expectArchiveExtraction(t, testingMocks.commandFactory, "unzip", func(dir string) []string {
+		return []string{"-q", "TestProducts.zip", "-d", dir}
+	}, nil, errors.New("exit status 9"))
+
+	_, err := step.extractTestProducts("TestProducts.zip")
+	require.EqualError(t, err, "failed to extract test products archive: exit status 9, output: End-of-central-directory signature not found.")
+}
+
+func Test_GivenUnsupportedArchive_WhenExtracted_ThenFails(t *testing.T) {
+	step, _ := createStepAndMocks(t)
+
+	_, err := step.extractTestProducts("TestProducts.rar")
+	require.EqualError(t, err, "unsupported test products archive (TestProducts.rar): expected a .zip or .tar.gz file")
+}
+
+// expectArchiveExtraction expects the extraction command of an archive, which writes the given files into the
+// extraction dir (passed to the args function). Returns the extraction dir, set once the command is created.
+func expectArchiveExtraction(t *testing.T, factory *mocks.Factory, name string, args func(dir string) []string, files map[string][]byte, runErr error) *string {
+	var dir string
+
+	cmd := new(mocks.Command)
+	cmd.On("PrintableCommandArgs").Return(name)
+	out := ""
+	if runErr != nil {
+		out = "End-of-central-directory signature not found."
+	}
+	cmd.On("RunAndReturnTrimmedCombinedOutput").Run(func(mock.Arguments) {
+		for pth, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, pth)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, pth), content, 0644))
+		}
+	}).Return(out, runErr)
+
+	factory.On("Create", name, mock.MatchedBy(func(actual []string) bool {
+		if len(actual) == 0 {
+			return false
+		}
+		candidate := actual[len(actual)-1]
+		if !reflect.DeepEqual(args(candidate), actual) {
+			return false
+		}
+		dir = candidate
+		return true
+	}), mock.Anything).Return(cmd).Once()
+
+	return &dir
+}
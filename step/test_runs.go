@@ -0,0 +1,218 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+)
+
+// findXctestruns resolves the xctestrun input, which can be an xctestrun file,
+// a directory containing xctestrun files or a glob pattern.
+func (s XcodebuildTester) findXctestruns(input string) ([]string, error) {
+	var pths []string
+
+	if strings.ContainsAny(input, "*?[") {
+		matches, err := filepath.Glob(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xctestrun glob pattern (%s): %w", input, err)
+		}
+		for _, match := range matches {
+			if filepath.Ext(match) == xctestrun.Ext {
+				pths = append(pths, match)
+			}
+		}
+	} else {
+		isDir, err := s.pathChecker.IsDirExists(input)
+		if err != nil {
+			return nil, err
+		}
+		if !isDir {
+			return []string{input}, nil
+		}
+
+		pths, err = xctestrun.Find(input)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pths) == 0 {
+		return nil, fmt.Errorf("no xctestrun file found for: %s", input)
+	}
+
+	sort.Strings(pths)
+	return pths, nil
+}
+
+// parseTestRuns parses the xctestrun files of the xctestrun input, which can be a test products bundle as well.
+// If the xctestrun files come from a test products archive, their build products are checked right away.
+func (s XcodebuildTester) parseTestRuns(input string, fromArchive bool) ([]*xctestrun.TestRun, error) {
+	var testRuns []*xctestrun.TestRun
+
+	if strings.HasSuffix(strings.TrimSuffix(input, "/"), xctestrun.TestProductsExt) {
+		var err error
+		testRuns, err = xctestrun.ParseTestProducts(input)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		xctestrunPaths, err := s.findXctestruns(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pth := range xctestrunPaths {
+			testRun, err := xctestrun.Parse(pth)
+			if err != nil {
+				return nil, err
+			}
+
+			if fromArchive {
+				if err := s.checkTestProducts(*testRun); err != nil {
+					return nil, err
+				}
+			}
+
+			testRuns = append(testRuns, testRun)
+		}
+	}
+
+	for _, testRun := range testRuns {
+		s.printTestRun(*testRun)
+	}
+
+	return testRuns, nil
+}
+
+// RunAll runs the tests of each xctestrun in turn and returns one result per xctestrun and destination.
+// A failing xctestrun doesn't stop running the remaining ones.
+func (s XcodebuildTester) RunAll(config Config) ([]Result, error) {
+	if config.isEmptyShard() {
+		s.logger.Println()
+		s.logger.Warnf("Skipping the tests: shard %d has no tests to run", config.ShardIndex)
+		return nil, nil
+	}
+
+	var (
+		results []Result
+		lastErr error
+		failed  []string
+	)
+
+	for _, testRun := range config.TestRuns {
+		runConfig, ok := config.forTestRun(testRun)
+		if !ok {
+			s.logger.Println()
+			s.logger.Warnf("Skipping %s: none of the selected tests or test plan configurations belong to it", testRun.Path)
+			continue
+		}
+
+		if len(config.TestRuns) > 1 {
+			s.logger.Println()
+			s.logger.Infof("Testing %s", testRun.Path)
+		}
+
+		for _, run := range s.runOnDestinations(runConfig) {
+			if run.result != nil {
+				results = append(results, *run.result)
+			}
+			if run.err != nil {
+				label := filepath.Base(testRun.Path)
+				if len(config.Destinations) > 1 {
+					label += " on " + destinationName(run.destination)
+				}
+
+				if len(config.TestRuns) > 1 || len(config.Destinations) > 1 {
+					s.logger.Errorf("%s: %s", label, run.err)
+				}
+				lastErr = run.err
+				failed = append(failed, label)
+			}
+		}
+	}
+
+	if len(results) == 0 && lastErr == nil {
+		return nil, fmt.Errorf("none of the xctestrun files contain the selected tests")
+	}
+
+	switch {
+	case len(failed) == 0:
+		return results, nil
+	case len(config.TestRuns) == 1 && len(config.Destinations) <= 1:
+		return results, lastErr
+	case len(config.Destinations) > 1:
+		return results, fmt.Errorf("tests failed for %d of %d test runs: %s", len(failed), len(config.TestRuns)*len(config.Destinations), strings.Join(failed, ", "))
+	default:
+		return results, fmt.Errorf("tests failed for %d of %d xctestrun files: %s", len(failed), len(config.TestRuns), strings.Join(failed, ", "))
+	}
+}
+
+// forTestRun returns the config of a single xctestrun, the test and configuration selections are narrowed down
+// to the ones belonging to the given xctestrun. Returns false if the xctestrun contains nothing to test.
+func (c Config) forTestRun(testRun *xctestrun.TestRun) (Config, bool) {
+	targets := map[string]bool{}
+	for _, name := range testRun.TestTargetNames() {
+		targets[name] = true
+	}
+	configurations := map[string]bool{}
+	for _, name := range testRun.ConfigurationNames() {
+		configurations[name] = true
+	}
+
+	runConfig := c
+	runConfig.Xctestrun = testRun.Path
+	runConfig.TestRun = testRun
+	runConfig.OnlyTesting = filterStrings(c.OnlyTesting, func(identifier string) bool { return targets[testTargetOf(identifier)] })
+	runConfig.SkipTesting = filterStrings(c.SkipTesting, func(identifier string) bool { return targets[testTargetOf(identifier)] })
+	runConfig.OnlyTestConfiguration = filterStrings(c.OnlyTestConfiguration, func(name string) bool { return configurations[name] })
+	runConfig.SkipTestConfiguration = filterStrings(c.SkipTestConfiguration, func(name string) bool { return configurations[name] })
+
+	if len(c.OnlyTesting) > 0 && len(runConfig.OnlyTesting) == 0 {
+		return Config{}, false
+	}
+	if len(c.OnlyTestConfiguration) > 0 && len(runConfig.OnlyTestConfiguration) == 0 {
+		return Config{}, false
+	}
+	return runConfig, true
+}
+
+func testTargetNames(testRuns []*xctestrun.TestRun) []string {
+	var names []string
+	for _, testRun := range testRuns {
+		names = append(names, testRun.TestTargetNames()...)
+	}
+	return uniqueStrings(names)
+}
+
+func configurationNames(testRuns []*xctestrun.TestRun) []string {
+	var names []string
+	for _, testRun := range testRuns {
+		names = append(names, testRun.ConfigurationNames()...)
+	}
+	return uniqueStrings(names)
+}
+
+func filterStrings(values []string, keep func(string) bool) []string {
+	var filtered []string
+	for _, value := range values {
+		if keep(value) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
+
+func uniqueStrings(values []string) []string {
+	var unique []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
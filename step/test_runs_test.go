@@ -0,0 +1,120 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcodebuild"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenXctestrunInput_WhenFindingXctestruns_ThenResolvesFileDirectoryAndGlob(t *testing.T) {
+	step, _ := createStepAndMocks(t)
+
+	dir := t.TempDir()
+	productsDir := filepath.Join(dir, "Build", "Products")
+	require.NoError(t, os.MkdirAll(filepath.Join(productsDir, "Debug-iphonesimulator", "App.app"), 0755))
+	unitTests := writeXctestrunTo(t, productsDir, "App_UnitTests_iphonesimulator17.5-arm64.xctestrun", "AppTests")
+	uiTests := writeXctestrunTo(t, productsDir, "App_UITests_iphonesimulator17.5-arm64.xctestrun", "AppUITests")
+	writeXctestrunTo(t, filepath.Join(productsDir, "Debug-iphonesimulator", "App.app"), "Bundled.xctestrun", "AppTests")
+
+	pths, err := step.findXctestruns(unitTests)
+	require.NoError(t, err)
+	require.Equal(t, []string{unitTests}, pths)
+
+	pths, err = step.findXctestruns(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{uiTests, unitTests}, pths)
+
+	pths, err = step.findXctestruns(filepath.Join(productsDir, "*_UITests_*.xctestrun"))
+	require.NoError(t, err)
+	require.Equal(t, []string{uiTests}, pths)
+
+	_, err = step.findXctestruns(filepath.Join(productsDir, "*.missing"))
+	require.EqualError(t, err, "no xctestrun file found for: "+filepath.Join(productsDir, "*.missing"))
+}
+
+func Test_GivenMultipleXctestruns_WhenOneFails_ThenAllRunAndFailureCombined(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	dir := t.TempDir()
+	unitTests, err := xctestrun.Parse(writeXctestrunTo(t, dir, "UnitTests.xctestrun", "AppTests"))
+	require.NoError(t, err)
+	uiTests, err := xctestrun.Parse(writeXctestrunTo(t, dir, "UITests.xctestrun", "AppUITests"))
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Xctestrun == unitTests.Path
+	})).Return("Test-UnitTests.xcresult", &xcodebuild.XcodebuildError{Reason: "failing tests (exit status 65)"})
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.Xctestrun == uiTests.Path
+	})).Return("Test-UITests.xcresult", nil)
+
+	config := Config{
+		TestRuns:    []*xctestrun.TestRun{unitTests, uiTests},
+		Destination: destination.Device{ID: "test-UDID"},
+	}
+
+	// When
+	results, err := step.RunAll(config)
+
+	// Then
+	require.EqualError(t, err, "tests failed for 1 of 2 xctestrun files: UnitTests.xctestrun")
+	require.Len(t, results, 2)
+	require.False(t, results[0].Succeeded)
+	require.Equal(t, "Test-UnitTests.xcresult", results[0].TestOutputDir)
+	require.True(t, results[1].Succeeded)
+	require.Equal(t, "Test-UITests.xcresult", results[1].TestOutputDir)
+}
+
+func Test_GivenTestSelection_WhenConfigCreatedForTestRun_ThenSelectionNarrowedDown(t *testing.T) {
+	dir := t.TempDir()
+	unitTests, err := xctestrun.Parse(writeXctestrunTo(t, dir, "UnitTests.xctestrun", "AppTests"))
+	require.NoError(t, err)
+	uiTests, err := xctestrun.Parse(writeXctestrunTo(t, dir, "UITests.xctestrun", "AppUITests"))
+	require.NoError(t, err)
+
+	config := Config{
+		OnlyTesting: []string{"AppTests/GameTests"},
+		SkipTesting: []string{"AppTests/GameTests/testSlow", "AppUITests"},
+	}
+
+	runConfig, ok := config.forTestRun(unitTests)
+	require.True(t, ok)
+	require.Equal(t, unitTests.Path, runConfig.Xctestrun)
+	require.Equal(t, []string{"AppTests/GameTests"}, runConfig.OnlyTesting)
+	require.Equal(t, []string{"AppTests/GameTests/testSlow"}, runConfig.SkipTesting)
+
+	_, ok = config.forTestRun(uiTests)
+	require.False(t, ok)
+}
+
+func Test_GivenTestProductsBundle_WhenStepRuns_ThenTestProductsPathUsed(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	testRuns, err := xctestrun.ParseTestProducts(filepath.Join("..", "xctestrun", "testdata", "BullsEye.xctestproducts"))
+	require.NoError(t, err)
+
+	testingMocks.xcodebuild.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestParams) bool {
+		return params.TestProductsPath == testRuns[0].TestProductsPath && params.TestPlan == "FullTests"
+	})).Return("Test-BullsEye-FullTests.xcresult", nil)
+
+	config := Config{
+		TestRuns:    testRuns,
+		Destination: destination.Device{ID: "test-UDID"},
+	}
+
+	// When
+	results, err := step.RunAll(config)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	testingMocks.xcodebuild.AssertExpectations(t)
+}
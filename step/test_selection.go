@@ -0,0 +1,136 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+)
+
+// validateTestIdentifiers checks the test identifiers (target, target/class or target/class/method)
+// against the test targets of the xctestrun. Classes and methods are not listed in the xctestrun,
+// so the returned unverified identifiers are only checked for their target.
+func validateTestIdentifiers(identifiers []string, targetNames []string) ([]string, error) {
+	isKnownTarget := map[string]bool{}
+	for _, name := range targetNames {
+		isKnownTarget[name] = true
+	}
+
+	var unverified []string
+	for _, identifier := range identifiers {
+		identifier = strings.TrimSpace(identifier)
+		components := strings.Split(identifier, "/")
+		if len(components) > 3 {
+			return nil, fmt.Errorf("invalid test identifier (%s): expected format is Target, Target/Class or Target/Class/method", identifier)
+		}
+		for _, component := range components {
+			if component == "" {
+				return nil, fmt.Errorf("invalid test identifier (%s): expected format is Target, Target/Class or Target/Class/method", identifier)
+			}
+		}
+
+		target := components[0]
+		if !isKnownTarget[target] {
+			if suggestion := closestMatch(target, targetNames); suggestion != "" {
+				return nil, fmt.Errorf("test target (%s) of test identifier (%s) not found in the xctestrun, did you mean %s?", target, identifier, suggestion)
+			}
+			return nil, fmt.Errorf("test target (%s) of test identifier (%s) not found in the xctestrun, available targets: %s", target, identifier, strings.Join(targetNames, ", "))
+		}
+
+		if len(components) > 1 {
+			unverified = append(unverified, identifier)
+		}
+	}
+
+	return unverified, nil
+}
+
+// validateTestConfigurations checks the test plan configuration names against the configurations of the xctestrun.
+func validateTestConfigurations(configurations []string, available []string) error {
+	if len(configurations) == 0 {
+		return nil
+	}
+
+	if len(available) == 0 {
+		return fmt.Errorf("test plan configurations are not available in xctestrun format version %d, build for testing with a test plan", xctestrun.FormatVersion1)
+	}
+
+	isKnown := map[string]bool{}
+	for _, name := range available {
+		isKnown[name] = true
+	}
+
+	for _, configuration := range configurations {
+		if isKnown[configuration] {
+			continue
+		}
+
+		if suggestion := closestMatch(configuration, available); suggestion != "" {
+			return fmt.Errorf("test plan configuration (%s) not found in the xctestrun, did you mean %s?", configuration, suggestion)
+		}
+		return fmt.Errorf("test plan configuration (%s) not found in the xctestrun, available configurations: %s", configuration, strings.Join(available, ", "))
+	}
+
+	return nil
+}
+
+// testTargetOf returns the test target component of a test identifier.
+func testTargetOf(identifier string) string {
+	return strings.Split(identifier, "/")[0]
+}
+
+// closestMatch returns the candidate with the smallest edit distance to the given value,
+// or an empty string if none of the candidates is similar enough.
+func closestMatch(value string, candidates []string) string {
+	match := ""
+	bestDistance := -1
+	for _, candidate := range candidates {
+		distance := levenshteinDistance(strings.ToLower(value), strings.ToLower(candidate))
+		if bestDistance == -1 || distance < bestDistance {
+			match = candidate
+			bestDistance = distance
+		}
+	}
+
+	maxDistance := len(value) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if bestDistance == -1 || bestDistance > maxDistance {
+		return ""
+	}
+	return match
+}
+
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xctestrun"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateTestIdentifiers(t *testing.T) {
+	targetNames := []string{"BullsEyeTests", "BullsEyeUITests"}
+
+	tests := []struct {
+		name           string
+		identifiers    []string
+		wantUnverified []string
+		wantErr        string
+	}{
+		{
+			name:        "Known targets",
+			identifiers: []string{"BullsEyeTests", "BullsEyeUITests"},
+		},
+		{
+			name:           "Classes and methods can't be verified",
+			identifiers:    []string{"BullsEyeTests/GameTests", "BullsEyeUITests/LaunchTests/testLaunch"},
+			wantUnverified: []string{"BullsEyeTests/GameTests", "BullsEyeUITests/LaunchTests/testLaunch"},
+		},
+		{
+			name:           "Surrounding whitespace",
+			identifiers:    []string{" BullsEyeTests\r", "\tBullsEyeUITests/LaunchTests/testLaunch  "},
+			wantUnverified: []string{"BullsEyeUITests/LaunchTests/testLaunch"},
+		},
+		{
+			name:        "Misspelled target",
+			identifiers: []string{"BullEyeTest/GameTests"},
+			wantErr:     "test target (BullEyeTest) of test identifier (BullEyeTest/GameTests) not found in the xctestrun, did you mean BullsEyeTests?",
+		},
+		{
+			name:        "Unknown target",
+			identifiers: []string{"NetworkingTests"},
+			wantErr:     "test target (NetworkingTests) of test identifier (NetworkingTests) not found in the xctestrun, available targets: BullsEyeTests, BullsEyeUITests",
+		},
+		{
+			name:        "Too many components",
+			identifiers: []string{"BullsEyeTests/GameTests/testScore/extra"},
+			wantErr:     "invalid test identifier (BullsEyeTests/GameTests/testScore/extra): expected format is Target, Target/Class or Target/Class/method",
+		},
+		{
+			name:        "Empty component",
+			identifiers: []string{"BullsEyeTests//testScore"},
+			wantErr:     "invalid test identifier (BullsEyeTests//testScore): expected format is Target, Target/Class or Target/Class/method",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unverified, err := validateTestIdentifiers(tt.identifiers, targetNames)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantUnverified, unverified)
+		})
+	}
+}
+
+func Test_GivenIdentifiersWithWhitespace_WhenProcessingTestConfiguration_ThenIdentifiersTrimmed(t *testing.T) {
+	step, _ := createStepAndMocks(t)
+	input := "BullsEyeTests/GameTests\r\n  BullsEyeUITests/LaunchTests/testLaunch\t\r\n\r\n BullsEyeSlowTests \n"
+	want := []string{"BullsEyeTests/GameTests", "BullsEyeUITests/LaunchTests/testLaunch", "BullsEyeSlowTests"}
+
+	identifiers, err := step.processTestConfiguration(input)
+	require.NoError(t, err)
+	require.Equal(t, want, identifiers)
+
+	pth := filepath.Join(t.TempDir(), "only_testing.txt")
+	require.NoError(t, os.WriteFile(pth, []byte(input), 0644))
+
+	identifiers, err = step.processTestConfiguration(pth)
+	require.NoError(t, err)
+	require.Equal(t, want, identifiers)
+}
+
+func Test_validateTestConfigurations(t *testing.T) {
+	available := []string{"English", "German"}
+
+	require.NoError(t, validateTestConfigurations([]string{"English", "German"}, available))
+	require.EqualError(t, validateTestConfigurations([]string{"Germn"}, available), "test plan configuration (Germn) not found in the xctestrun, did you mean German?")
+	require.EqualError(t, validateTestConfigurations([]string{"Accessibility"}, available), "test plan configuration (Accessibility) not found in the xctestrun, available configurations: English, German")
+
+	formatV1 := xctestrun.TestRun{FormatVersion: xctestrun.FormatVersion1, TestConfigurations: []xctestrun.TestConfiguration{{}}}
+	require.NoError(t, validateTestConfigurations(nil, formatV1.ConfigurationNames()))
+	require.EqualError(t, validateTestConfigurations([]string{"English"}, formatV1.ConfigurationNames()), "test plan configurations are not available in xctestrun format version 1, build for testing with a test plan")
+}
@@ -0,0 +1,158 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+const (
+	testSummaryFileName          = "test_summary.md"
+	testSummaryAnnotationContext = "xcode-test-without-building"
+	// maxSummaryFailureMessageLength keeps the failure messages of the summary readable.
+	maxSummaryFailureMessageLength = 300
+)
+
+// testRunSummary is the summary of a single test run, TestResults is nil if the test result bundle couldn't be read.
+type testRunSummary struct {
+	Result      Result
+	TestResults *xcresult.TestResults
+}
+
+// exportTestSummary writes the Markdown summary of the test runs to the deploy dir,
+// and publishes it as a build annotation if the bitrise CLI is available.
+func (s XcodebuildTester) exportTestSummary(config Config, results []Result, reports map[string]*xcresult.TestResults) {
+	var summaries []testRunSummary
+	for _, result := range results {
+		summaries = append(summaries, testRunSummary{Result: result, TestResults: reports[result.TestOutputDir]})
+	}
+
+	markdown := renderTestSummary(summaries)
+
+	pth := filepath.Join(config.DeployDir, testSummaryFileName)
+	if err := os.WriteFile(pth, []byte(markdown), 0644); err != nil {
+		s.logger.Warnf("Failed to export the test summary: %s", err)
+		return
+	}
+	s.exportOutput(testSummaryKey, pth)
+
+	style := "success"
+	for _, result := range results {
+		if !result.Succeeded {
+			style = "error"
+		}
+	}
+
+	cmd := s.commandFactory.Create("bitrise", []string{":annotations", "annotate", markdown, "--style", style, "--context", testSummaryAnnotationContext}, nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			// The bitrise CLI is not available outside of Bitrise builds.
+			return
+		}
+		s.logger.Warnf("Failed to publish the test summary as a build annotation: %s, output: %s", err, out)
+	}
+}
+
+// renderTestSummary renders the totals, the failed tests and the retry attempts of each test run.
+func renderTestSummary(summaries []testRunSummary) string {
+	var reports []*xcresult.TestResults
+	succeeded := len(summaries) > 0
+	for _, summary := range summaries {
+		if !summary.Result.Succeeded {
+			succeeded = false
+		}
+		if summary.TestResults != nil {
+			reports = append(reports, summary.TestResults)
+		}
+	}
+	total := totalSummary(reports)
+
+	var b strings.Builder
+	if succeeded {
+		b.WriteString("## ✅ Tests passed\n\n")
+	} else {
+		b.WriteString("## ❌ Tests failed\n\n")
+	}
+
+	b.WriteString("| Tests | Passed | Failed | Skipped | Duration |\n")
+	b.WriteString("| ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %s |\n", total.TotalTestCount, total.PassedTests+total.ExpectedFailures, total.FailedTests, total.SkippedTests, formatDuration(total.Duration))
+
+	for _, summary := range summaries {
+		b.WriteString("\n")
+		renderTestRunSummary(&b, summary)
+	}
+
+	return b.String()
+}
+
+func renderTestRunSummary(b *strings.Builder, summary testRunSummary) {
+	result := summary.Result
+
+	status := "✅"
+	if !result.Succeeded {
+		status = "❌"
+	}
+	fmt.Fprintf(b, "### %s %s\n\n", status, filepath.Base(result.Xctestrun))
+	fmt.Fprintf(b, "- Destination: %s\n", destinationName(result.Destination))
+
+	if summary.TestResults != nil {
+		testRunTotal := summary.TestResults.Summary()
+		fmt.Fprintf(b, "- Tests: %d passed, %d failed, %d skipped in %s\n", testRunTotal.PassedTests+testRunTotal.ExpectedFailures, testRunTotal.FailedTests, testRunTotal.SkippedTests, formatDuration(testRunTotal.Duration))
+	} else {
+		b.WriteString("- Tests: the test results are not available\n")
+	}
+
+	if len(result.Attempts) > 1 {
+		var reasons []string
+		for _, attempt := range result.Attempts[1:] {
+			reasons = append(reasons, attempt.Reason)
+		}
+		fmt.Fprintf(b, "- Attempts: %d (retried because of: %s)\n", len(result.Attempts), strings.Join(reasons, "; "))
+	}
+	if len(result.QuarantinedFailures) > 0 {
+		fmt.Fprintf(b, "- Quarantined failures: %s\n", strings.Join(result.QuarantinedFailures, ", "))
+	}
+
+	if summary.TestResults == nil {
+		return
+	}
+
+	var failed []xcresult.TestCase
+	for _, testCase := range summary.TestResults.TestCases() {
+		if testCase.Result == xcresult.TestResultFailed {
+			failed = append(failed, testCase)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+
+	b.WriteString("\n| Failed test | Failure |\n")
+	b.WriteString("| --- | --- |\n")
+	for _, testCase := range failed {
+		fmt.Fprintf(b, "| `%s` | %s |\n", testCase.Identifier, markdownTableCell(strings.Join(testCase.Failures, "\n")))
+	}
+}
+
+// markdownTableCell escapes the text to fit into a single Markdown table cell.
+func markdownTableCell(text string) string {
+	if runes := []rune(text); len(runes) > maxSummaryFailureMessageLength {
+		text = string(runes[:maxSummaryFailureMessageLength]) + "…"
+	}
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+func formatDuration(duration time.Duration) string {
+	if duration < time.Second {
+		return duration.Round(time.Millisecond).String()
+	}
+	return duration.Round(time.Second).String()
+}
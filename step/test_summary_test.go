@@ -0,0 +1,165 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_renderTestSummary(t *testing.T) {
+	failing := &xcresult.TestResults{TestNodes: []xcresult.TestNode{{
+		NodeType: "Unit test bundle",
+		Name:     "AppTests",
+		Children: []xcresult.TestNode{{
+			NodeType: "Test Suite",
+			Name:     "LoginTests",
+			Children: []xcresult.TestNode{
+				{NodeType: "Test Case", Name: "testLogin()", Result: "Passed", DurationInSeconds: 61.5},
+				{NodeType: "Test Case", Name: "testLogout()", Result: "Failed", DurationInSeconds: 2, Children: []xcresult.TestNode{
+					{NodeType: "Failure Message", Name: "LoginTests.swift:42: XCTAssertEqual failed: (\"a|b\") is not equal to (\"c\")"},
+				}},
+				{NodeType: "Test Case", Name: "testSignUp()", Result: "Skipped"},
+			},
+		}},
+	}}}
+
+	summaries := []testRunSummary{
+		{
+			Result: Result{
+				Xctestrun:   "/path/to/App.xctestrun",
+				Destination: destination.Device{Name: "iPhone 15", OS: "17.5"},
+				Attempts: []Attempt{
+					{Number: 1, ExitCode: 65},
+					{Number: 2, Reason: "Test runner never began executing tests after launching.", ExitCode: 65},
+				},
+			},
+			TestResults: failing,
+		},
+		{
+			Result: Result{
+				Xctestrun:   "/path/to/Widget.xctestrun",
+				Destination: destination.Device{Name: "iPhone 15", OS: "17.5"},
+				Succeeded:   true,
+			},
+		},
+	}
+
+	require.Equal(t, "## ❌ Tests failed\n"+
+		"\n"+
+		"| Tests | Passed | Failed | Skipped | Duration |\n"+
+		"| ---: | ---: | ---: | ---: | ---: |\n"+
+		"| 3 | 1 | 1 | 1 | 1m4s |\n"+
+		"\n"+
+		"### ❌ App.xctestrun\n"+
+		"\n"+
+		"- Destination: iPhone 15 (17.5)\n"+
+		"- Tests: 1 passed, 1 failed, 1 skipped in 1m4s\n"+
+		"- Attempts: 2 (retried because of: Test runner never began executing tests after launching.)\n"+
+		"\n"+
+		"| Failed test | Failure |\n"+
+		"| --- | --- |\n"+
+		"| `AppTests/LoginTests/testLogout` | LoginTests.swift:42: XCTAssertEqual failed: (\"a\\|b\") is not equal to (\"c\") |\n"+
+		"\n"+
+		"### ✅ Widget.xctestrun\n"+
+		"\n"+
+		"- Destination: iPhone 15 (17.5)\n"+
+		"- Tests: the test results are not available\n", renderTestSummary(summaries))
+}
+
+func Test_GivenDeployDir_WhenStepExportsOutputs_ThenTestSummaryExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	annotation := expectTestSummaryAnnotation(testingMocks.commandFactory, "success")
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()": xcresult.TestResultPassed,
+	}), nil)
+
+	// When
+	err := step.ExportOutputs(Config{DeployDir: deployDir}, []Result{{Xctestrun: "App.xctestrun", TestOutputDir: "Test-App.xcresult", DeployDir: deployDir, Succeeded: true}})
+
+	// Then
+	require.NoError(t, err)
+
+	pth := filepath.Join(deployDir, testSummaryFileName)
+	content, err := os.ReadFile(pth)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "## ✅ Tests passed")
+	testingMocks.envRepository.AssertCalled(t, "Set", testSummaryKey, pth)
+	testingMocks.commandFactory.AssertCalled(t, "Create", "bitrise", []string{":annotations", "annotate", string(content), "--style", "success", "--context", testSummaryAnnotationContext}, mock.Anything)
+	annotation.AssertCalled(t, "RunAndReturnTrimmedCombinedOutput")
+}
+
+func Test_GivenFailedTests_WhenStepExportsOutputs_ThenTestSummaryAnnotatedAsError(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	annotation := expectTestSummaryAnnotation(testingMocks.commandFactory, "error")
+	testingMocks.xcresultReader.On("ReadTestResults", mock.Anything).Return(testResultsOf(map[string]string{
+		"AppTests/LoginTests/testLogin()": xcresult.TestResultFailed,
+	}), nil)
+
+	// When
+	err := step.ExportOutputs(Config{DeployDir: deployDir}, []Result{
+		{Xctestrun: "App.xctestrun", TestOutputDir: "Test-App.xcresult", DeployDir: deployDir, Succeeded: true},
+		{Xctestrun: "Widget.xctestrun", TestOutputDir: "Test-Widget.xcresult", DeployDir: deployDir, Succeeded: false},
+	})
+
+	// Then
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(deployDir, testSummaryFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "## ❌ Tests failed")
+	testingMocks.commandFactory.AssertCalled(t, "Create", "bitrise", []string{":annotations", "annotate", string(content), "--style", "error", "--context", testSummaryAnnotationContext}, mock.Anything)
+	annotation.AssertCalled(t, "RunAndReturnTrimmedCombinedOutput")
+}
+
+func Test_GivenBitriseCLIUnavailable_WhenStepExportsOutputs_ThenTestSummaryStillExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App.xcresult").Return(&xcresult.TestResults{}, nil)
+
+	annotation := new(mocks.Command)
+	annotation.On("RunAndReturnTrimmedCombinedOutput").Return("", fmt.Errorf("executing command failed (bitrise): %w", &exec.Error{Name: "bitrise", Err: exec.ErrNotFound}))
+	testingMocks.commandFactory.On("Create", "bitrise", mock.Anything, mock.Anything).Return(annotation)
+
+	// When
+	err := step.ExportOutputs(Config{DeployDir: deployDir}, []Result{{Xctestrun: "App.xctestrun", TestOutputDir: "Test-App.xcresult", DeployDir: deployDir, Succeeded: true}})
+
+	// Then
+	require.NoError(t, err)
+	testingMocks.envRepository.AssertCalled(t, "Set", testSummaryKey, filepath.Join(deployDir, testSummaryFileName))
+}
+
+// expectTestSummaryAnnotation expects the test summary to be published as a build annotation with the given style,
+// mock.Anything matches any style.
+func expectTestSummaryAnnotation(factory *mocks.Factory, style string) *mocks.Command {
+	cmd := new(mocks.Command)
+	cmd.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	factory.On("Create", "bitrise", mock.MatchedBy(func(args []string) bool {
+		return len(args) == 7 && args[0] == ":annotations" && args[1] == "annotate" &&
+			(style == mock.Anything || args[4] == style) && args[6] == testSummaryAnnotationContext
+	}), mock.Anything).Return(cmd)
+
+	return cmd
+}
@@ -0,0 +1,142 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+)
+
+const (
+	testTimingsFileName     = "test_timings.json"
+	shardAssignmentFileName = "test_shard_assignment.json"
+
+	// defaultTestDuration is the estimated duration (in seconds) of a test unit if no timing is known at all.
+	defaultTestDuration = 1.0
+)
+
+// TestTimings holds the durations (in seconds) of the test classes, keyed by TestTarget/TestClass identifiers.
+type TestTimings struct {
+	TestClasses map[string]float64 `json:"test_classes"`
+}
+
+func readTestTimings(pth string) (TestTimings, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return TestTimings{}, fmt.Errorf("failed to read test timings file: %w", err)
+	}
+
+	var timings TestTimings
+	if err := json.Unmarshal(content, &timings); err != nil {
+		return TestTimings{}, fmt.Errorf("failed to parse test timings file (%s): %w", pth, err)
+	}
+	return timings, nil
+}
+
+// duration returns the known duration of a test unit, which is either a test class or a whole test target.
+func (t TestTimings) duration(unit string) (float64, bool) {
+	if duration, ok := t.TestClasses[unit]; ok {
+		return duration, true
+	}
+	if strings.Contains(unit, "/") {
+		return 0, false
+	}
+
+	var (
+		total float64
+		found bool
+	)
+	for class, duration := range t.TestClasses {
+		if testTargetOf(class) == unit {
+			total += duration
+			found = true
+		}
+	}
+	return total, found
+}
+
+// estimateDurations returns the durations of the test units, the units without a known duration
+// get the average duration of the known units.
+func (t TestTimings) estimateDurations(units []string) map[string]float64 {
+	durations := map[string]float64{}
+	var (
+		known   float64
+		unknown []string
+	)
+	for _, unit := range units {
+		if duration, ok := t.duration(unit); ok {
+			durations[unit] = duration
+			known += duration
+		} else {
+			unknown = append(unknown, unit)
+		}
+	}
+
+	estimate := defaultTestDuration
+	if knownCount := len(units) - len(unknown); knownCount > 0 && known > 0 {
+		estimate = known / float64(knownCount)
+	}
+	for _, unit := range unknown {
+		durations[unit] = estimate
+	}
+	return durations
+}
+
+// collectTestTimings collects the test class durations from the test reports of the results,
+// the durations of the given previous timings are kept for the classes not run now.
+// Each result is a run on a single destination, a class run on multiple destinations
+// is timed by its slowest run, as that's the duration a shard running it has to account for.
+func collectTestTimings(results []Result, reports map[string]*xcresult.TestResults, previous TestTimings) TestTimings {
+	timings := TestTimings{TestClasses: map[string]float64{}}
+	for class, duration := range previous.TestClasses {
+		timings.TestClasses[class] = duration
+	}
+
+	measured := map[string]float64{}
+	for _, result := range results {
+		testResults := reports[result.TestOutputDir]
+		if result.TestOutputDir == "" || testResults == nil {
+			continue
+		}
+
+		classDurations := map[string]float64{}
+		for _, testCase := range testResults.TestCases() {
+			classDurations[testCase.Target+"/"+testCase.Class] += testCase.Duration.Seconds()
+		}
+		for class, duration := range classDurations {
+			if duration > measured[class] {
+				measured[class] = duration
+			}
+		}
+	}
+
+	for class, duration := range measured {
+		timings.TestClasses[class] = duration
+	}
+	return timings
+}
+
+func (s XcodebuildTester) exportTestTimings(config Config, results []Result, reports map[string]*xcresult.TestResults) {
+	timings := collectTestTimings(results, reports, config.TestTimings)
+	if len(timings.TestClasses) == 0 {
+		return
+	}
+
+	pth := filepath.Join(config.DeployDir, testTimingsFileName)
+	if err := writeJSON(pth, timings); err != nil {
+		s.logger.Warnf("Failed to export test timings: %s", err)
+		return
+	}
+	s.exportOutput(testTimingsKey, pth)
+}
+
+func writeJSON(pth string, value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pth, content, 0644)
+}
@@ -0,0 +1,140 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readTestTimings(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "test_timings.json")
+	require.NoError(t, os.WriteFile(pth, []byte(`{"test_classes": {"AppTests/LoginTests": 12.5}}`), 0644))
+
+	timings, err := readTestTimings(pth)
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"AppTests/LoginTests": 12.5}, timings.TestClasses)
+
+	require.NoError(t, os.WriteFile(pth, []byte(`[]`), 0644))
+	_, err = readTestTimings(pth)
+	require.Error(t, err)
+}
+
+func TestTestTimings_estimateDurations(t *testing.T) {
+	timings := TestTimings{TestClasses: map[string]float64{
+		"AppTests/LoginTests":   20,
+		"AppTests/ProfileTests": 10,
+		"AppUITests/Onboarding": 60,
+	}}
+
+	require.Equal(t, map[string]float64{
+		"AppTests/LoginTests":    20,
+		"AppUITests/Onboarding":  60,
+		"AppTests/SettingsTests": 40,
+	}, timings.estimateDurations([]string{"AppTests/LoginTests", "AppUITests/Onboarding", "AppTests/SettingsTests"}))
+
+	require.Equal(t, map[string]float64{
+		"AppTests":         30,
+		"AppUITests":       60,
+		"AppSnapshotTests": 45,
+	}, timings.estimateDurations([]string{"AppTests", "AppUITests", "AppSnapshotTests"}))
+
+	require.Equal(t, map[string]float64{"AppTests/LoginTests": defaultTestDuration}, TestTimings{}.estimateDurations([]string{"AppTests/LoginTests"}))
+}
+
+func Test_GivenTestResults_WhenCollectingTestTimings_ThenClassDurationsMerged(t *testing.T) {
+	// Given
+	reports := map[string]*xcresult.TestResults{"Test-App.xcresult": {TestNodes: []xcresult.TestNode{{
+		NodeType: "Unit test bundle",
+		Name:     "AppTests",
+		Children: []xcresult.TestNode{{
+			NodeType: "Test Suite",
+			Name:     "LoginTests",
+			Children: []xcresult.TestNode{
+				{NodeType: "Test Case", Name: "testLogin()", DurationInSeconds: 1.5},
+				{NodeType: "Test Case", Name: "testLogout()", DurationInSeconds: 2.5},
+			},
+		}},
+	}}}}
+
+	previous := TestTimings{TestClasses: map[string]float64{
+		"AppTests/LoginTests":   30,
+		"AppTests/ProfileTests": 10,
+	}}
+
+	// When
+	timings := collectTestTimings([]Result{{TestOutputDir: "Test-App.xcresult"}, {}, {TestOutputDir: "Test-Unreadable.xcresult"}}, reports, previous)
+
+	// Then
+	require.Equal(t, map[string]float64{
+		"AppTests/LoginTests":   4,
+		"AppTests/ProfileTests": 10,
+	}, timings.TestClasses)
+	require.Equal(t, 30.0, previous.TestClasses["AppTests/LoginTests"])
+}
+
+func Test_GivenTestResultsOfMultipleDestinations_WhenCollectingTestTimings_ThenSlowestDestinationKept(t *testing.T) {
+	// Given
+	loginTestsOf := func(durations ...float64) *xcresult.TestResults {
+		var testCases []xcresult.TestNode
+		for i, duration := range durations {
+			testCases = append(testCases, xcresult.TestNode{NodeType: "Test Case", Name: fmt.Sprintf("test%d()", i), DurationInSeconds: duration})
+		}
+		return &xcresult.TestResults{TestNodes: []xcresult.TestNode{{
+			NodeType: "Unit test bundle",
+			Name:     "AppTests",
+			Children: []xcresult.TestNode{{NodeType: "Test Suite", Name: "LoginTests", Children: testCases}},
+		}}}
+	}
+	reports := map[string]*xcresult.TestResults{
+		"Test-App-iPhone.xcresult": loginTestsOf(1.5, 2.5),
+		"Test-App-iPad.xcresult":   loginTestsOf(3, 3),
+	}
+
+	// When
+	timings := collectTestTimings([]Result{
+		{TestOutputDir: "Test-App-iPhone.xcresult", Destination: destination.Device{Name: "iPhone 15", OS: "17.5"}},
+		{TestOutputDir: "Test-App-iPad.xcresult", Destination: destination.Device{Name: "iPad Air", OS: "17.5"}},
+	}, reports, TestTimings{})
+
+	// Then
+	require.Equal(t, map[string]float64{"AppTests/LoginTests": 6}, timings.TestClasses)
+}
+
+func Test_GivenShardedRun_WhenStepExportsOutputs_ThenTimingsAndAssignmentExported(t *testing.T) {
+	// Given
+	step, testingMocks := createStepAndMocks(t)
+
+	deployDir := t.TempDir()
+	testingMocks.envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
+	testingMocks.outputExporter.On("ZipAndExportOutput", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	expectTestSummaryAnnotation(testingMocks.commandFactory, mock.Anything)
+	testingMocks.xcresultReader.On("ReadTestResults", "Test-App-shard-0-of-2.xcresult").Return(&xcresult.TestResults{}, nil)
+
+	assignment := assignRoundRobin([]string{"AppTests", "AppUITests"}, 2)
+	config := Config{
+		DeployDir:       deployDir,
+		ShardCount:      2,
+		TestTimings:     TestTimings{TestClasses: map[string]float64{"AppTests/LoginTests": 12}},
+		ShardAssignment: &assignment,
+	}
+
+	// When
+	err := step.ExportOutputs(config, []Result{{TestOutputDir: "Test-App-shard-0-of-2.xcresult", DeployDir: deployDir, Succeeded: true}})
+
+	// Then
+	require.NoError(t, err)
+
+	timings, err := readTestTimings(filepath.Join(deployDir, testTimingsFileName))
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"AppTests/LoginTests": 12}, timings.TestClasses)
+
+	testingMocks.envRepository.AssertCalled(t, "Set", testTimingsKey, filepath.Join(deployDir, testTimingsFileName))
+	testingMocks.envRepository.AssertCalled(t, "Set", shardAssignmentKey, filepath.Join(deployDir, shardAssignmentFileName))
+	testingMocks.xcresultReader.AssertNumberOfCalls(t, "ReadTestResults", 1)
+}
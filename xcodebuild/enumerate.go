@@ -0,0 +1,82 @@
+package xcodebuild
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+type testEnumeration struct {
+	Errors []string `json:"errors"`
+	Values []struct {
+		TestPlan     string `json:"testPlan"`
+		EnabledTests []struct {
+			Identifier string `json:"identifier"`
+		} `json:"enabledTests"`
+	} `json:"values"`
+}
+
+// EnumerateTests lists the identifiers of the tests selected by the params without running them (Xcode 15+).
+// The identifiers are in the TestTarget/TestClass/testMethod() format, as reported by xcodebuild, sorted.
+func (x xcodebuild) EnumerateTests(params TestParams) ([]string, error) {
+	tempDir, err := x.pathProvider.CreateTempDir("TestEnumeration")
+	if err != nil {
+		return nil, err
+	}
+	outputPth := path.Join(tempDir, "tests.json")
+
+	options := createEnumerateTestsOptions(params, outputPth)
+	cmd := x.commandFactory.Create("xcodebuild", options, nil)
+
+	x.logger.TDonef(cmd.PrintableCommandArgs())
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to enumerate tests: %w, output: %s", err, out)
+	}
+
+	content, err := os.ReadFile(outputPth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test enumeration: %w", err)
+	}
+
+	return parseTestEnumeration(content)
+}
+
+func createEnumerateTestsOptions(params TestParams, outputPth string) []string {
+	options := append([]string{"test-without-building"}, testProductsOptions(params)...)
+	options = append(options, "-destination", params.Destination.XcodebuildDestination())
+	options = append(options, testSelectionOptions(params)...)
+	return append(options,
+		"-enumerate-tests",
+		"-test-enumeration-style", "flat",
+		"-test-enumeration-format", "json",
+		"-test-enumeration-output-path", outputPth,
+	)
+}
+
+func parseTestEnumeration(content []byte) ([]string, error) {
+	var enumeration testEnumeration
+	if err := json.Unmarshal(content, &enumeration); err != nil {
+		return nil, fmt.Errorf("failed to parse test enumeration: %w", err)
+	}
+	if len(enumeration.Errors) > 0 {
+		return nil, fmt.Errorf("test enumeration failed: %s", strings.Join(enumeration.Errors, ", "))
+	}
+
+	var identifiers []string
+	seen := map[string]bool{}
+	for _, value := range enumeration.Values {
+		for _, test := range value.EnabledTests {
+			if seen[test.Identifier] {
+				continue
+			}
+			seen[test.Identifier] = true
+			identifiers = append(identifiers, test.Identifier)
+		}
+	}
+	sort.Strings(identifiers)
+
+	return identifiers, nil
+}
@@ -1,9 +1,10 @@
 package xcodebuild
 
 type XcodebuildError struct {
-	Reason string
-	Err    error
-	Log    string
+	Reason   string
+	Err      error
+	Log      string
+	ExitCode int
 }
 
 func (err *XcodebuildError) Error() string {
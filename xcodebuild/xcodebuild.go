@@ -24,8 +24,30 @@ const (
 	TestRepetitionRetryOnFailure = "retry_on_failure"
 )
 
+// TestParams describes a single test-without-building run.
+// If TestProductsPath is set, the tests of the test products bundle are run instead of the Xctestrun.
+// ResultBundleSuffix is appended to the name of the test result bundle, to tell apart the bundles of the same xctestrun.
+// If EnableCodeCoverage is set, the code coverage of the tests is collected in the test result bundle.
+type TestParams struct {
+	Xctestrun                      string
+	TestProductsPath               string
+	TestPlan                       string
+	OnlyTesting                    []string
+	SkipTesting                    []string
+	OnlyTestConfiguration          []string
+	SkipTestConfiguration          []string
+	Destination                    destination.Device
+	TestRepetitionMode             string
+	MaximumTestRepetitions         int
+	RelaunchTestsForEachRepetition bool
+	ResultBundleSuffix             string
+	EnableCodeCoverage             bool
+	Options                        []string
+}
+
 type Xcodebuild interface {
-	TestWithoutBuilding(xctestrun string, onlyTesting, skipTesting []string, destination destination.Device, testRepetitionMode string, maximumTestRepetitions int, relaunchTestsForEachRepetition bool, options ...string) (string, error)
+	TestWithoutBuilding(params TestParams) (string, error)
+	EnumerateTests(params TestParams) ([]string, error)
 }
 
 type xcodebuild struct {
@@ -44,7 +66,7 @@ func New(logger log.Logger, commandFactory command.Factory, pathProvider pathuti
 	}
 }
 
-func (x xcodebuild) TestWithoutBuilding(xctestrun string, onlyTesting, skipTesting []string, destination destination.Device, testRepetitionMode string, maximumTestRepetitions int, relaunchTestsForEachRepetition bool, opts ...string) (string, error) {
+func (x xcodebuild) TestWithoutBuilding(params TestParams) (string, error) {
 	logFile, err := x.createXcodebuildLogFile()
 	if err != nil {
 		return "", err
@@ -57,24 +79,14 @@ func (x xcodebuild) TestWithoutBuilding(xctestrun string, onlyTesting, skipTesti
 
 	outputWriter := io.MultiWriter(os.Stdout, logFile)
 
-	outputDir, err := x.createTestOutputDir(xctestrun)
+	outputDir, err := x.createTestOutputDir(params)
 	if err != nil {
 		return "", err
 	}
 
 	var (
-		destinationParam = destination.XcodebuildDestination()
-		options          = createXcodebuildOptions(
-			xctestrun,
-			onlyTesting,
-			skipTesting,
-			destinationParam,
-			testRepetitionMode,
-			maximumTestRepetitions,
-			relaunchTestsForEachRepetition,
-			outputDir,
-			opts...)
-		cmd = x.commandFactory.Create("xcodebuild", options, &command.Opts{
+		options = createXcodebuildOptions(params, outputDir)
+		cmd     = x.commandFactory.Create("xcodebuild", options, &command.Opts{
 			Stdout: outputWriter,
 			Stderr: outputWriter,
 			Env:    []string{"NSUnbufferedIO=YES"},
@@ -96,14 +108,30 @@ func (x xcodebuild) createXcodebuildLogFile() (*os.File, error) {
 	return os.Create(path.Join(tempDir, "test-without-building.log"))
 }
 
-func (x xcodebuild) createTestOutputDir(xctestrun string) (string, error) {
+func (x xcodebuild) createTestOutputDir(params TestParams) (string, error) {
 	tempDir, err := x.pathProvider.CreateTempDir("TestOutput")
 	if err != nil {
 		return "", err
 	}
 
-	fileName := strings.TrimSuffix(filepath.Base(xctestrun), filepath.Ext(xctestrun))
-	return path.Join(tempDir, fmt.Sprintf("Test-%s.xcresult", fileName)), nil
+	return path.Join(tempDir, ResultBundleName(params)), nil
+}
+
+// ResultBundleName returns the name of the test result bundle of the test run.
+func ResultBundleName(params TestParams) string {
+	var fileName string
+	if params.TestProductsPath != "" {
+		fileName = strings.TrimSuffix(filepath.Base(params.TestProductsPath), filepath.Ext(params.TestProductsPath))
+		if params.TestPlan != "" {
+			fileName += "-" + params.TestPlan
+		}
+	} else {
+		fileName = strings.TrimSuffix(filepath.Base(params.Xctestrun), filepath.Ext(params.Xctestrun))
+	}
+	if params.ResultBundleSuffix != "" {
+		fileName += "-" + params.ResultBundleSuffix
+	}
+	return fmt.Sprintf("Test-%s.xcresult", fileName)
 }
 
 func (x xcodebuild) handleError(xcodebuildErr error, outputDir string, logFile *os.File) (string, error) {
@@ -129,9 +157,10 @@ func (x xcodebuild) handleError(xcodebuildErr error, outputDir string, logFile *
 			}
 
 			return outputDir, &XcodebuildError{
-				Reason: fmt.Sprintf("failing tests (exit status %v)", exerr.ExitCode()),
-				Err:    xcodebuildErr,
-				Log:    string(log),
+				Reason:   fmt.Sprintf("failing tests (exit status %v)", exerr.ExitCode()),
+				Err:      xcodebuildErr,
+				Log:      string(log),
+				ExitCode: exerr.ExitCode(),
 			}
 		}
 
@@ -141,39 +170,70 @@ func (x xcodebuild) handleError(xcodebuildErr error, outputDir string, logFile *
 	return outputDir, nil
 }
 
-func createXcodebuildOptions(xctestrun string, onlyTesting, skipTesting []string, destination, testRepetitionMode string, maximumTestRepetitions int, relaunchTestsForEachRepetition bool, outputDir string, opts ...string) []string {
-	options := []string{"test-without-building", "-xctestrun", xctestrun, "-destination", destination, "-resultBundlePath", outputDir}
+func createXcodebuildOptions(params TestParams, outputDir string) []string {
+	options := append([]string{"test-without-building"}, testProductsOptions(params)...)
+	options = append(options, "-destination", params.Destination.XcodebuildDestination(), "-resultBundlePath", outputDir)
 
-	switch testRepetitionMode {
+	switch params.TestRepetitionMode {
 	case TestRepetitionUntilFailure:
 		options = append(options, "-run-tests-until-failure")
 	case TestRepetitionRetryOnFailure:
 		options = append(options, "-retry-tests-on-failure")
 	}
-	if testRepetitionMode != TestRepetitionNone {
-		options = append(options, "-test-iterations", strconv.Itoa(maximumTestRepetitions))
+	if params.TestRepetitionMode != TestRepetitionNone {
+		options = append(options, "-test-iterations", strconv.Itoa(params.MaximumTestRepetitions))
 	}
-	if relaunchTestsForEachRepetition {
+	if params.RelaunchTestsForEachRepetition {
 		options = append(options, "-test-repetition-relaunch-enabled", "YES")
 	}
+	if params.EnableCodeCoverage {
+		options = append(options, "-enableCodeCoverage", "YES")
+	}
+
+	options = append(options, testSelectionOptions(params)...)
+
+	return append(options, params.Options...)
+}
+
+func testProductsOptions(params TestParams) []string {
+	if params.TestProductsPath != "" {
+		options := []string{"-testProductsPath", params.TestProductsPath}
+		if params.TestPlan != "" {
+			options = append(options, "-testPlan", params.TestPlan)
+		}
+		return options
+	}
+	return []string{"-xctestrun", params.Xctestrun}
+}
 
-	if 0 < len(onlyTesting) {
+func testSelectionOptions(params TestParams) []string {
+	var options []string
+
+	if 0 < len(params.OnlyTesting) {
 		var args []string
-		for _, identifier := range onlyTesting {
+		for _, identifier := range params.OnlyTesting {
 			args = append(args, fmt.Sprintf("-only-testing:%s", identifier))
 		}
 		options = append(options, args...)
 	}
 
-	if 0 < len(skipTesting) {
+	if 0 < len(params.SkipTesting) {
 		var args []string
-		for _, identifier := range skipTesting {
+		for _, identifier := range params.SkipTesting {
 			args = append(args, fmt.Sprintf("-skip-testing:%s", identifier))
 		}
 		options = append(options, args...)
 	}
 
-	return append(options, opts...)
+	for _, configuration := range params.OnlyTestConfiguration {
+		options = append(options, "-only-test-configuration", configuration)
+	}
+
+	for _, configuration := range params.SkipTestConfiguration {
+		options = append(options, "-skip-test-configuration", configuration)
+	}
+
+	return options
 }
 
 func isDirEmpty(name string) (bool, error) {
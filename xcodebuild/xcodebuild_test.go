@@ -2,6 +2,7 @@ package xcodebuild
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
@@ -40,10 +41,178 @@ func TestTestConfiguration(t *testing.T) {
 		"target6/testClass1/testFunction",
 	}
 
-	_, err := xcbuild.TestWithoutBuilding("test.xctestrun", onlyTesting, skipTesting, device, "none", 0, false)
+	_, err := xcbuild.TestWithoutBuilding(TestParams{
+		Xctestrun:          "test.xctestrun",
+		OnlyTesting:        onlyTesting,
+		SkipTesting:        skipTesting,
+		Destination:        device,
+		TestRepetitionMode: "none",
+	})
 	require.NoError(t, err)
 
 	pathProviderMock.AssertExpectations(t)
 	commandMock.AssertExpectations(t)
 	factoryMock.AssertExpectations(t)
 }
+
+func TestTestPlanConfiguration(t *testing.T) {
+	commandMock := new(mocks.Command)
+	commandMock.On("PrintableCommandArgs").Return("")
+	commandMock.On("Run").Return(nil)
+
+	params := []string{"test-without-building", "-xctestrun", "test.xctestrun", "-destination", "id=test-UDID", "-resultBundlePath", "/test/path/Test-test.xcresult", "-only-test-configuration", "English", "-only-test-configuration", "German", "-skip-test-configuration", "French", "-parallel-testing-enabled", "YES"}
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcodebuild", params, mock.Anything).Return(commandMock, nil).Once()
+
+	pathProviderMock := new(mocks.PathProvider)
+	pathProviderMock.On("CreateTempDir", "xcodebuild").Return(os.TempDir(), nil).Once()
+	pathProviderMock.On("CreateTempDir", "TestOutput").Return("/test/path", nil).Once()
+
+	xcbuild := New(log.NewLogger(), factoryMock, pathProviderMock, pathutil.NewPathChecker())
+
+	_, err := xcbuild.TestWithoutBuilding(TestParams{
+		Xctestrun:             "test.xctestrun",
+		OnlyTestConfiguration: []string{"English", "German"},
+		SkipTestConfiguration: []string{"French"},
+		Destination:           destination.Device{ID: "test-UDID"},
+		TestRepetitionMode:    TestRepetitionNone,
+		Options:               []string{"-parallel-testing-enabled", "YES"},
+	})
+	require.NoError(t, err)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestTestProducts(t *testing.T) {
+	commandMock := new(mocks.Command)
+	commandMock.On("PrintableCommandArgs").Return("")
+	commandMock.On("Run").Return(nil)
+
+	params := []string{"test-without-building", "-testProductsPath", "/products/BullsEye.xctestproducts", "-testPlan", "FullTests", "-destination", "id=test-UDID", "-resultBundlePath", "/test/path/Test-BullsEye-FullTests.xcresult", "-only-testing:BullsEyeTests"}
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcodebuild", params, mock.Anything).Return(commandMock, nil).Once()
+
+	pathProviderMock := new(mocks.PathProvider)
+	pathProviderMock.On("CreateTempDir", "xcodebuild").Return(os.TempDir(), nil).Once()
+	pathProviderMock.On("CreateTempDir", "TestOutput").Return("/test/path", nil).Once()
+
+	xcbuild := New(log.NewLogger(), factoryMock, pathProviderMock, pathutil.NewPathChecker())
+
+	_, err := xcbuild.TestWithoutBuilding(TestParams{
+		Xctestrun:          "/products/BullsEye.xctestproducts/Tests/0/BullsEye.xctestrun",
+		TestProductsPath:   "/products/BullsEye.xctestproducts",
+		TestPlan:           "FullTests",
+		OnlyTesting:        []string{"BullsEyeTests"},
+		Destination:        destination.Device{ID: "test-UDID"},
+		TestRepetitionMode: TestRepetitionNone,
+	})
+	require.NoError(t, err)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestResultBundleSuffix(t *testing.T) {
+	commandMock := new(mocks.Command)
+	commandMock.On("PrintableCommandArgs").Return("")
+	commandMock.On("Run").Return(nil)
+
+	params := []string{"test-without-building", "-xctestrun", "test.xctestrun", "-destination", "id=test-UDID", "-resultBundlePath", "/test/path/Test-test-shard-1-of-3.xcresult", "-only-testing:target1/testClass1"}
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcodebuild", params, mock.Anything).Return(commandMock, nil).Once()
+
+	pathProviderMock := new(mocks.PathProvider)
+	pathProviderMock.On("CreateTempDir", "xcodebuild").Return(os.TempDir(), nil).Once()
+	pathProviderMock.On("CreateTempDir", "TestOutput").Return("/test/path", nil).Once()
+
+	xcbuild := New(log.NewLogger(), factoryMock, pathProviderMock, pathutil.NewPathChecker())
+
+	_, err := xcbuild.TestWithoutBuilding(TestParams{
+		Xctestrun:          "test.xctestrun",
+		OnlyTesting:        []string{"target1/testClass1"},
+		Destination:        destination.Device{ID: "test-UDID"},
+		TestRepetitionMode: TestRepetitionNone,
+		ResultBundleSuffix: "shard-1-of-3",
+	})
+	require.NoError(t, err)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestCodeCoverage(t *testing.T) {
+	commandMock := new(mocks.Command)
+	commandMock.On("PrintableCommandArgs").Return("")
+	commandMock.On("Run").Return(nil)
+
+	params := []string{"test-without-building", "-xctestrun", "test.xctestrun", "-destination", "id=test-UDID", "-resultBundlePath", "/test/path/Test-test.xcresult", "-enableCodeCoverage", "YES", "-only-testing:target1"}
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcodebuild", params, mock.Anything).Return(commandMock, nil).Once()
+
+	pathProviderMock := new(mocks.PathProvider)
+	pathProviderMock.On("CreateTempDir", "xcodebuild").Return(os.TempDir(), nil).Once()
+	pathProviderMock.On("CreateTempDir", "TestOutput").Return("/test/path", nil).Once()
+
+	xcbuild := New(log.NewLogger(), factoryMock, pathProviderMock, pathutil.NewPathChecker())
+
+	_, err := xcbuild.TestWithoutBuilding(TestParams{
+		Xctestrun:          "test.xctestrun",
+		OnlyTesting:        []string{"target1"},
+		Destination:        destination.Device{ID: "test-UDID"},
+		TestRepetitionMode: TestRepetitionNone,
+		EnableCodeCoverage: true,
+	})
+	require.NoError(t, err)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestEnumerateTests(t *testing.T) {
+	tempDir := t.TempDir()
+	outputPth := filepath.Join(tempDir, "tests.json")
+	require.NoError(t, os.WriteFile(outputPth, []byte(`{
+  "errors" : [],
+  "values" : [
+    {
+      "disabledTests" : [
+        { "identifier" : "BullsEyeTests/BullsEyeTests/testPerformanceExample()" }
+      ],
+      "enabledTests" : [
+        { "identifier" : "BullsEyeUITests/BullsEyeUITests/testGameStyleSwitch()" },
+        { "identifier" : "BullsEyeTests/BullsEyeTests/testScoreIsComputedWhenGuessIsHigherThanTarget()" }
+      ],
+      "testPlan" : "FullTests"
+    }
+  ]
+}`), 0644))
+
+	commandMock := new(mocks.Command)
+	commandMock.On("PrintableCommandArgs").Return("")
+	commandMock.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	params := []string{"test-without-building", "-xctestrun", "test.xctestrun", "-destination", "id=test-UDID", "-skip-testing:BullsEyeSlowTests", "-enumerate-tests", "-test-enumeration-style", "flat", "-test-enumeration-format", "json", "-test-enumeration-output-path", outputPth}
+
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcodebuild", params, mock.Anything).Return(commandMock, nil).Once()
+
+	pathProviderMock := new(mocks.PathProvider)
+	pathProviderMock.On("CreateTempDir", "TestEnumeration").Return(tempDir, nil).Once()
+
+	xcbuild := New(log.NewLogger(), factoryMock, pathProviderMock, pathutil.NewPathChecker())
+
+	tests, err := xcbuild.EnumerateTests(TestParams{
+		Xctestrun:   "test.xctestrun",
+		SkipTesting: []string{"BullsEyeSlowTests"},
+		Destination: destination.Device{ID: "test-UDID"},
+		Options:     []string{"-parallel-testing-enabled", "YES"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"BullsEyeTests/BullsEyeTests/testScoreIsComputedWhenGuessIsHigherThanTarget()",
+		"BullsEyeUITests/BullsEyeUITests/testGameStyleSwitch()",
+	}, tests)
+
+	factoryMock.AssertExpectations(t)
+}
@@ -0,0 +1,80 @@
+package xcresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const attachmentsManifestFileName = "manifest.json"
+
+// TestAttachments are the attachments of a test, as listed in the manifest of `xcresulttool export attachments` (Xcode 16+).
+// TestIdentifier is in the TestClass/testMethod() format, TestIdentifierURL also contains the test target
+// (test://com.apple.xcode/Project/TestTarget/TestClass/testMethod).
+type TestAttachments struct {
+	TestIdentifier    string       `json:"testIdentifier"`
+	TestIdentifierURL string       `json:"testIdentifierURL"`
+	Attachments       []Attachment `json:"attachments"`
+}
+
+// Attachment is an exported attachment (like a screenshot or a screen recording) of a test.
+// RepetitionNumber is the test iteration the attachment was created in, if the test was repeated.
+type Attachment struct {
+	ExportedFileName           string  `json:"exportedFileName"`
+	SuggestedHumanReadableName string  `json:"suggestedHumanReadableName"`
+	IsAssociatedWithFailure    bool    `json:"isAssociatedWithFailure"`
+	Timestamp                  float64 `json:"timestamp"`
+	ConfigurationName          string  `json:"configurationName"`
+	DeviceName                 string  `json:"deviceName"`
+	DeviceID                   string  `json:"deviceId"`
+	RepetitionNumber           int     `json:"repetitionNumber"`
+}
+
+// TestTarget returns the test target of the test, or an empty string if the test identifier URL is not available.
+func (t TestAttachments) TestTarget() string {
+	components := strings.Split(strings.TrimPrefix(t.TestIdentifierURL, "test://"), "/")
+	if len(components) < 3 {
+		return ""
+	}
+	return components[len(components)-3]
+}
+
+// TestClass returns the test class of the test.
+func (t TestAttachments) TestClass() string {
+	components := strings.Split(t.TestIdentifier, "/")
+	if len(components) < 2 {
+		return ""
+	}
+	return components[len(components)-2]
+}
+
+// TestMethod returns the test method of the test, without the parentheses.
+func (t TestAttachments) TestMethod() string {
+	components := strings.Split(t.TestIdentifier, "/")
+	return strings.TrimSuffix(components[len(components)-1], "()")
+}
+
+// ExportAttachments exports the attachments of the result bundle to the output dir, and returns them by their tests.
+// If onlyFailures is set, only the attachments of the failed tests are exported.
+func (r reader) ExportAttachments(xcresultPth, outputDir string, onlyFailures bool) ([]TestAttachments, error) {
+	args := []string{"export", "attachments", "--path", xcresultPth, "--output-path", outputDir}
+	if onlyFailures {
+		args = append(args, "--only-failures")
+	}
+	if out, err := r.xcresulttool(args...); err != nil {
+		return nil, fmt.Errorf("failed to export attachments of %s: %w, output: %s", xcresultPth, err, out)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, attachmentsManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments manifest: %w", err)
+	}
+
+	var attachments []TestAttachments
+	if err := json.Unmarshal(content, &attachments); err != nil {
+		return nil, fmt.Errorf("failed to parse attachments manifest: %w", err)
+	}
+	return attachments, nil
+}
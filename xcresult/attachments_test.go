@@ -0,0 +1,65 @@
+package xcresult_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAttachments(t *testing.T) {
+	outputDir := t.TempDir()
+	// The manifest is written by xcresulttool.
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "manifest.json"), []byte(fixture(t, "xcode16_attachments_manifest.json")), 0644))
+
+	factoryMock := new(mocks.Factory)
+	expectXcresulttool(t, factoryMock, []string{"export", "attachments", "--path", "Test-App.xcresult", "--output-path", outputDir, "--only-failures"}, "", nil)
+
+	reader := xcresult.NewReader(factoryMock)
+
+	attachments, err := reader.ExportAttachments("Test-App.xcresult", outputDir, true)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	require.Equal(t, "BullsEyeUITests", attachments[0].TestTarget())
+	require.Equal(t, "BullsEyeUITests", attachments[0].TestClass())
+	require.Equal(t, "testGameStyleSwitch", attachments[0].TestMethod())
+	require.Equal(t, []xcresult.Attachment{
+		{
+			ExportedFileName:           "0A1B2C3D-4E5F-6A7B-8C9D-0E1F2A3B4C5D.png",
+			SuggestedHumanReadableName: "Screenshot_1_0A1B2C3D-4E5F-6A7B-8C9D-0E1F2A3B4C5D.png",
+			IsAssociatedWithFailure:    true,
+			Timestamp:                  1736337372.421,
+			ConfigurationName:          "Test Scheme Action",
+			DeviceName:                 "iPhone 15",
+			DeviceID:                   "8E6D7A38-1F4B-4C1E-9B8A-2C3D4E5F6A7B",
+			RepetitionNumber:           1,
+		},
+		{
+			ExportedFileName:           "1B2C3D4E-5F6A-7B8C-9D0E-1F2A3B4C5D6E.mp4",
+			SuggestedHumanReadableName: "Screen Recording_1_1B2C3D4E-5F6A-7B8C-9D0E-1F2A3B4C5D6E.mp4",
+			Timestamp:                  1736337380.05,
+			ConfigurationName:          "Test Scheme Action",
+			DeviceName:                 "iPhone 15",
+			DeviceID:                   "8E6D7A38-1F4B-4C1E-9B8A-2C3D4E5F6A7B",
+			RepetitionNumber:           2,
+		},
+	}, attachments[0].Attachments)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestExportAttachments_Fails(t *testing.T) {
+	factoryMock := new(mocks.Factory)
+	expectXcresulttool(t, factoryMock, []string{"export", "attachments", "--path", "Test-App.xcresult", "--output-path", "/tmp/attachments"}, "Error: Unknown option 'attachments'", errors.New("exit status 64"))
+
+	reader := xcresult.NewReader(factoryMock)
+
+	_, err := reader.ExportAttachments("Test-App.xcresult", "/tmp/attachments", false)
+	require.EqualError(t, err, "failed to export attachments of Test-App.xcresult: exit status 64, output: Error: Unknown option 'attachments'")
+
+	factoryMock.AssertExpectations(t)
+}
@@ -0,0 +1,73 @@
+package xcresult
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CoverageReport is the code coverage report of a result bundle, as listed by `xccov view --report --json`.
+// LineCoverage is the ratio of the covered and the executable lines (0-1).
+type CoverageReport struct {
+	CoveredLines    int              `json:"coveredLines"`
+	ExecutableLines int              `json:"executableLines"`
+	LineCoverage    float64          `json:"lineCoverage"`
+	Targets         []TargetCoverage `json:"targets"`
+}
+
+// TargetCoverage is the code coverage of a build product (like App.app or Kit.framework).
+type TargetCoverage struct {
+	Name            string         `json:"name"`
+	CoveredLines    int            `json:"coveredLines"`
+	ExecutableLines int            `json:"executableLines"`
+	LineCoverage    float64        `json:"lineCoverage"`
+	Files           []FileCoverage `json:"files"`
+}
+
+type FileCoverage struct {
+	Name            string  `json:"name"`
+	Path            string  `json:"path"`
+	CoveredLines    int     `json:"coveredLines"`
+	ExecutableLines int     `json:"executableLines"`
+	LineCoverage    float64 `json:"lineCoverage"`
+}
+
+// LineCoverage is the execution count of a source line, as listed by `xccov view --archive --json`.
+type LineCoverage struct {
+	Line           int  `json:"line"`
+	IsExecutable   bool `json:"isExecutable"`
+	ExecutionCount int  `json:"executionCount"`
+}
+
+// ReadCoverageReport reads the code coverage report of the result bundle.
+// The coverage is only collected if the tests were run with code coverage enabled.
+func (r reader) ReadCoverageReport(xcresultPth string) (*CoverageReport, error) {
+	out, err := r.xccov("view", "--report", "--json", xcresultPth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code coverage report of %s: %w", xcresultPth, err)
+	}
+
+	var report CoverageReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse code coverage report: %w", err)
+	}
+	return &report, nil
+}
+
+// ReadLineCoverage reads the line coverage of the source files of the result bundle, by their paths.
+func (r reader) ReadLineCoverage(xcresultPth string) (map[string][]LineCoverage, error) {
+	out, err := r.xccov("view", "--archive", "--json", xcresultPth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read line coverage of %s: %w", xcresultPth, err)
+	}
+
+	var lines map[string][]LineCoverage
+	if err := json.Unmarshal([]byte(out), &lines); err != nil {
+		return nil, fmt.Errorf("failed to parse line coverage: %w", err)
+	}
+	return lines, nil
+}
+
+func (r reader) xccov(args ...string) (string, error) {
+	cmd := r.commandFactory.Create("xcrun", append([]string{"xccov"}, args...), nil)
+	return cmd.RunAndReturnTrimmedOutput()
+}
@@ -0,0 +1,75 @@
+package xcresult_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCoverageReport(t *testing.T) {
+	factoryMock := new(mocks.Factory)
+	expectXccov(factoryMock, []string{"view", "--report", "--json", "Test-App.xcresult"}, fixture(t, "xccov_report.json"), nil)
+
+	reader := xcresult.NewReader(factoryMock)
+
+	report, err := reader.ReadCoverageReport("Test-App.xcresult")
+	require.NoError(t, err)
+	require.Equal(t, &xcresult.CoverageReport{
+		CoveredLines:    6,
+		ExecutableLines: 10,
+		LineCoverage:    0.6,
+		Targets: []xcresult.TargetCoverage{{
+			Name:            "BullsEye.app",
+			CoveredLines:    6,
+			ExecutableLines: 10,
+			LineCoverage:    0.6,
+			Files: []xcresult.FileCoverage{
+				{Name: "BullsEyeGame.swift", Path: "/Users/vagrant/git/BullsEye/BullsEyeGame.swift", CoveredLines: 4, ExecutableLines: 6, LineCoverage: 0.6666666666666666},
+				{Name: "ContentView.swift", Path: "/Users/vagrant/git/BullsEye/ContentView.swift", CoveredLines: 2, ExecutableLines: 4, LineCoverage: 0.5},
+			},
+		}},
+	}, report)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestReadCoverageReport_NoCoverage(t *testing.T) {
+	factoryMock := new(mocks.Factory)
+	expectXccov(factoryMock, []string{"view", "--report", "--json", "Test-App.xcresult"}, "Error: Test-App.xcresult does not contain code coverage data.", errors.New("exit status 1"))
+
+	reader := xcresult.NewReader(factoryMock)
+
+	_, err := reader.ReadCoverageReport("Test-App.xcresult")
+	require.EqualError(t, err, "failed to read code coverage report of Test-App.xcresult: exit status 1")
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestReadLineCoverage(t *testing.T) {
+	factoryMock := new(mocks.Factory)
+	expectXccov(factoryMock, []string{"view", "--archive", "--json", "Test-App.xcresult"}, fixture(t, "xccov_archive.json"), nil)
+
+	reader := xcresult.NewReader(factoryMock)
+
+	lines, err := reader.ReadLineCoverage("Test-App.xcresult")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]xcresult.LineCoverage{
+		"/Users/vagrant/git/BullsEye/BullsEyeGame.swift": {
+			{Line: 1},
+			{Line: 2, IsExecutable: true, ExecutionCount: 3},
+			{Line: 3, IsExecutable: true},
+		},
+	}, lines)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func expectXccov(factoryMock *mocks.Factory, args []string, out string, err error) {
+	commandMock := new(mocks.Command)
+	commandMock.On("RunAndReturnTrimmedOutput").Return(out, err)
+	factoryMock.On("Create", "xcrun", append([]string{"xccov"}, args...), mock.Anything).Return(commandMock).Once()
+}
@@ -0,0 +1,258 @@
+package xcresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// legacyStatuses maps the test statuses of the legacy API to the test results of the test-results API.
+var legacyStatuses = map[string]string{
+	"Success":          TestResultPassed,
+	"Failure":          TestResultFailed,
+	"Skipped":          TestResultSkipped,
+	"Expected Failure": TestResultExpectedFailure,
+}
+
+type legacyValue struct {
+	Value string `json:"_value"`
+}
+
+// legacyActionsInvocationRecord is the root object of a result bundle, as listed by `xcresulttool get --legacy --format json`.
+type legacyActionsInvocationRecord struct {
+	Actions struct {
+		Values []legacyActionRecord `json:"_values"`
+	} `json:"actions"`
+	Issues struct {
+		TestFailureSummaries struct {
+			Values []legacyTestFailureIssueSummary `json:"_values"`
+		} `json:"testFailureSummaries"`
+	} `json:"issues"`
+}
+
+type legacyActionRecord struct {
+	RunDestination struct {
+		TargetDeviceRecord struct {
+			Identifier             legacyValue `json:"identifier"`
+			Name                   legacyValue `json:"name"`
+			ModelName              legacyValue `json:"modelName"`
+			OperatingSystemVersion legacyValue `json:"operatingSystemVersion"`
+			NativeArchitecture     legacyValue `json:"nativeArchitecture"`
+			PlatformRecord         struct {
+				UserDescription legacyValue `json:"userDescription"`
+			} `json:"platformRecord"`
+		} `json:"targetDeviceRecord"`
+	} `json:"runDestination"`
+	ActionResult struct {
+		TestsRef *struct {
+			ID legacyValue `json:"id"`
+		} `json:"testsRef"`
+	} `json:"actionResult"`
+}
+
+type legacyTestFailureIssueSummary struct {
+	Message                             legacyValue `json:"message"`
+	ProducingTarget                     legacyValue `json:"producingTarget"`
+	TestCaseName                        legacyValue `json:"testCaseName"`
+	DocumentLocationInCreatingWorkspace *struct {
+		URL legacyValue `json:"url"`
+	} `json:"documentLocationInCreatingWorkspace"`
+}
+
+// message returns the failure message in the format of the test-results API: prefixed with the file name and line number of the failure.
+func (s legacyTestFailureIssueSummary) message() string {
+	if s.DocumentLocationInCreatingWorkspace == nil {
+		return s.Message.Value
+	}
+
+	// The document location is in the file:///path/File.swift#EndingLineNumber=41&StartingLineNumber=41 format, with zero-based line numbers.
+	location, err := url.Parse(s.DocumentLocationInCreatingWorkspace.URL.Value)
+	if err != nil || location.Path == "" {
+		return s.Message.Value
+	}
+
+	fragment, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		return s.Message.Value
+	}
+
+	line, err := strconv.Atoi(fragment.Get("StartingLineNumber"))
+	if err != nil {
+		return fmt.Sprintf("%s: %s", path.Base(location.Path), s.Message.Value)
+	}
+	return fmt.Sprintf("%s:%d: %s", path.Base(location.Path), line+1, s.Message.Value)
+}
+
+// legacyActionTestPlanRunSummaries is the test report of an action, referenced by the testsRef of the action.
+type legacyActionTestPlanRunSummaries struct {
+	Summaries struct {
+		Values []struct {
+			TestableSummaries struct {
+				Values []legacyTestableSummary `json:"_values"`
+			} `json:"testableSummaries"`
+		} `json:"_values"`
+	} `json:"summaries"`
+}
+
+type legacyTestableSummary struct {
+	Name     legacyValue `json:"name"`
+	TestKind legacyValue `json:"testKind"`
+	Tests    struct {
+		Values []legacyTest `json:"_values"`
+	} `json:"tests"`
+}
+
+// legacyTest is either a test group (ActionTestSummaryGroup) or a test method (ActionTestMetadata).
+type legacyTest struct {
+	Name       legacyValue `json:"name"`
+	Identifier legacyValue `json:"identifier"`
+	Duration   legacyValue `json:"duration"`
+	TestStatus legacyValue `json:"testStatus"`
+	Subtests   struct {
+		Values []legacyTest `json:"_values"`
+	} `json:"subtests"`
+}
+
+// readLegacyTestResults reads the test report of the result bundle with the legacy API,
+// and converts it to the structure of the test-results API.
+func (r reader) readLegacyTestResults(xcresultPth string) (*TestResults, error) {
+	// Xcode 16+ only serves the legacy API with the --legacy flag, before Xcode 16 the legacy API is the only API, without the flag.
+	// The flag support is detected with the first object, the rest of the objects are read the same way.
+	legacyFlag := true
+	out, err := r.getLegacyObject(xcresultPth, "", legacyFlag)
+	if err != nil {
+		legacyFlag = false
+		var withoutFlagErr error
+		out, withoutFlagErr = r.getLegacyObject(xcresultPth, "", legacyFlag)
+		if withoutFlagErr != nil {
+			return nil, fmt.Errorf("%w, output: %s", err, out)
+		}
+	}
+
+	var record legacyActionsInvocationRecord
+	if err := json.Unmarshal([]byte(out), &record); err != nil {
+		return nil, fmt.Errorf("failed to parse result bundle: %w", err)
+	}
+
+	var summaries []legacyActionTestPlanRunSummaries
+	for _, action := range record.Actions.Values {
+		if action.ActionResult.TestsRef == nil {
+			continue
+		}
+
+		out, err := r.getLegacyObject(xcresultPth, action.ActionResult.TestsRef.ID.Value, legacyFlag)
+		if err != nil {
+			return nil, fmt.Errorf("%w, output: %s", err, out)
+		}
+
+		var summary legacyActionTestPlanRunSummaries
+		if err := json.Unmarshal([]byte(out), &summary); err != nil {
+			return nil, fmt.Errorf("failed to parse test summaries: %w", err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return convertLegacyTestResults(record, summaries), nil
+}
+
+func (r reader) getLegacyObject(xcresultPth, id string, legacyFlag bool) (string, error) {
+	args := []string{"get"}
+	if legacyFlag {
+		args = append(args, "--legacy")
+	}
+	args = append(args, "--format", "json", "--path", xcresultPth)
+	if id != "" {
+		args = append(args, "--id", id)
+	}
+
+	return r.xcresulttool(args...)
+}
+
+func convertLegacyTestResults(record legacyActionsInvocationRecord, summaries []legacyActionTestPlanRunSummaries) *TestResults {
+	// The failures are keyed by their test target too, as test classes of different test targets can have the same name.
+	failures := map[string][]string{}
+	for _, failure := range record.Issues.TestFailureSummaries.Values {
+		// The test case name is in the TestClass.testMethod() format.
+		key := legacyFailureKey(failure.ProducingTarget.Value, strings.Replace(failure.TestCaseName.Value, ".", "/", 1))
+		failures[key] = append(failures[key], failure.message())
+	}
+
+	results := &TestResults{}
+	for _, action := range record.Actions.Values {
+		device := action.RunDestination.TargetDeviceRecord
+		results.Devices = append(results.Devices, Device{
+			ID:           device.Identifier.Value,
+			Name:         device.Name.Value,
+			Architecture: device.NativeArchitecture.Value,
+			ModelName:    device.ModelName.Value,
+			Platform:     device.PlatformRecord.UserDescription.Value,
+			OSVersion:    device.OperatingSystemVersion.Value,
+		})
+	}
+
+	for _, summary := range summaries {
+		for _, runSummary := range summary.Summaries.Values {
+			for _, testable := range runSummary.TestableSummaries.Values {
+				results.TestNodes = append(results.TestNodes, convertLegacyTestableSummary(testable, failures))
+			}
+		}
+	}
+	return results
+}
+
+// convertLegacyTestableSummary converts the test groups of a test target into test suites of test cases, one suite per test class.
+func convertLegacyTestableSummary(testable legacyTestableSummary, failures map[string][]string) TestNode {
+	targetNode := TestNode{NodeType: nodeTypeUnitTests, Name: testable.Name.Value}
+	if testable.TestKind.Value == "UI" {
+		targetNode.NodeType = nodeTypeUITests
+	}
+
+	suiteIndexes := map[string]int{}
+	for _, test := range legacyTestMethods(testable.Tests.Values) {
+		// The identifier of a test method is in the TestClass/testMethod() format.
+		class := strings.SplitN(test.Identifier.Value, "/", 2)[0]
+		index, ok := suiteIndexes[class]
+		if !ok {
+			index = len(targetNode.Children)
+			suiteIndexes[class] = index
+			targetNode.Children = append(targetNode.Children, TestNode{NodeType: nodeTypeTestSuite, Name: class})
+		}
+
+		testNode := TestNode{
+			Identifier: test.Identifier.Value,
+			NodeType:   nodeTypeTestCase,
+			Name:       test.Name.Value,
+			Result:     legacyStatuses[test.TestStatus.Value],
+		}
+		if duration, err := strconv.ParseFloat(test.Duration.Value, 64); err == nil {
+			testNode.DurationInSeconds = duration
+		}
+		for _, message := range failures[legacyFailureKey(testable.Name.Value, test.Identifier.Value)] {
+			testNode.Children = append(testNode.Children, TestNode{NodeType: nodeTypeFailureMessage, Name: message})
+		}
+
+		targetNode.Children[index].Children = append(targetNode.Children[index].Children, testNode)
+	}
+	return targetNode
+}
+
+// legacyFailureKey returns the TestTarget/TestClass/testMethod() key of a test failure.
+func legacyFailureKey(target, identifier string) string {
+	return target + "/" + identifier
+}
+
+// legacyTestMethods returns the test methods of the test groups, the test methods are the tests with a test status.
+func legacyTestMethods(tests []legacyTest) []legacyTest {
+	var methods []legacyTest
+	for _, test := range tests {
+		if test.TestStatus.Value != "" {
+			methods = append(methods, test)
+			continue
+		}
+		methods = append(methods, legacyTestMethods(test.Subtests.Values)...)
+	}
+	return methods
+}
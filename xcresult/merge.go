@@ -0,0 +1,31 @@
+package xcresult
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+type Merger interface {
+	Merge(xcresultPths []string, outputPth string) error
+}
+
+type merger struct {
+	commandFactory command.Factory
+}
+
+func NewMerger(commandFactory command.Factory) Merger {
+	return merger{commandFactory: commandFactory}
+}
+
+// Merge merges the result bundles into a single result bundle at outputPth.
+func (m merger) Merge(xcresultPths []string, outputPth string) error {
+	args := append([]string{"xcresulttool", "merge"}, xcresultPths...)
+	args = append(args, "--output-path", outputPth)
+
+	cmd := m.commandFactory.Create("xcrun", args, nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to merge result bundles: %w, output: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package xcresult_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	commandMock := new(mocks.Command)
+	commandMock.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	args := []string{"xcresulttool", "merge", "Test-App-simulator-1.xcresult", "Test-App-simulator-2.xcresult", "--output-path", "Test-App.xcresult"}
+	factoryMock := new(mocks.Factory)
+	factoryMock.On("Create", "xcrun", args, mock.Anything).Return(commandMock).Once()
+
+	merger := xcresult.NewMerger(factoryMock)
+
+	err := merger.Merge([]string{"Test-App-simulator-1.xcresult", "Test-App-simulator-2.xcresult"}, "Test-App.xcresult")
+	require.NoError(t, err)
+
+	factoryMock.AssertExpectations(t)
+}
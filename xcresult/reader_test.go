@@ -0,0 +1,126 @@
+package xcresult_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/mocks"
+	"github.com/bitrise-steplib/bitrise-step-xcode-test-without-building/xcresult"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTestResults(t *testing.T) {
+	factoryMock := new(mocks.Factory)
+	expectXcresulttool(t, factoryMock, []string{"get", "test-results", "tests", "--path", "Test-App.xcresult"}, fixture(t, "xcode16_test_results.json"), nil)
+
+	reader := xcresult.NewReader(factoryMock)
+
+	results, err := reader.ReadTestResults("Test-App.xcresult")
+	require.NoError(t, err)
+	require.Equal(t, 4, results.Summary().TotalTestCount)
+
+	factoryMock.AssertExpectations(t)
+}
+
+func TestReadTestResults_Legacy(t *testing.T) {
+	tests := []struct {
+		name            string
+		legacyFlagError error
+	}{
+		{name: "Xcode 16 legacy API"},
+		{name: "Xcode 15 API", legacyFlagError: errors.New("exit status 64")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factoryMock := new(mocks.Factory)
+			expectXcresulttool(t, factoryMock, []string{"get", "test-results", "tests", "--path", "Test-App.xcresult"}, "Error: Unknown option 'test-results'", errors.New("exit status 64"))
+			if tt.legacyFlagError != nil {
+				expectXcresulttool(t, factoryMock, []string{"get", "--legacy", "--format", "json", "--path", "Test-App.xcresult"}, "Error: Unknown option '--legacy'", tt.legacyFlagError)
+				expectXcresulttool(t, factoryMock, []string{"get", "--format", "json", "--path", "Test-App.xcresult"}, fixture(t, "legacy_actions_invocation_record.json"), nil)
+				expectXcresulttool(t, factoryMock, []string{"get", "--format", "json", "--path", "Test-App.xcresult", "--id", "0~a1b2c3d4e5f6"}, fixture(t, "legacy_test_plan_run_summaries.json"), nil)
+			} else {
+				expectXcresulttool(t, factoryMock, []string{"get", "--legacy", "--format", "json", "--path", "Test-App.xcresult"}, fixture(t, "legacy_actions_invocation_record.json"), nil)
+				expectXcresulttool(t, factoryMock, []string{"get", "--legacy", "--format", "json", "--path", "Test-App.xcresult", "--id", "0~a1b2c3d4e5f6"}, fixture(t, "legacy_test_plan_run_summaries.json"), nil)
+			}
+
+			reader := xcresult.NewReader(factoryMock)
+
+			results, err := reader.ReadTestResults("Test-App.xcresult")
+			require.NoError(t, err)
+			require.Equal(t, []xcresult.TestCase{
+				{
+					Identifier: "BullsEyeTests/BullsEyeTests/testScoreIsComputedWhenGuessIsHigherThanTarget",
+					Target:     "BullsEyeTests",
+					Class:      "BullsEyeTests",
+					Name:       "testScoreIsComputedWhenGuessIsHigherThanTarget()",
+					Result:     xcresult.TestResultPassed,
+					Duration:   4200 * time.Microsecond,
+				},
+				{
+					Identifier: "BullsEyeTests/BullsEyeSlowTests/testSlowNetworkRoundTrip",
+					Target:     "BullsEyeTests",
+					Class:      "BullsEyeSlowTests",
+					Name:       "testSlowNetworkRoundTrip()",
+					Result:     xcresult.TestResultFailed,
+					Duration:   2 * time.Second,
+					Failures:   []string{`BullsEyeSlowTests.swift:42: XCTAssertEqual failed: ("404") is not equal to ("200")`},
+				},
+				{
+					Identifier: "BullsEyeUITests/BullsEyeUITests/testGameStyleSwitch",
+					Target:     "BullsEyeUITests",
+					Class:      "BullsEyeUITests",
+					Name:       "testGameStyleSwitch()",
+					Result:     xcresult.TestResultPassed,
+					Duration:   64500 * time.Millisecond,
+				},
+				{
+					Identifier: "BullsEyeUITests/BullsEyeSlowTests/testSlowNetworkRoundTrip",
+					Target:     "BullsEyeUITests",
+					Class:      "BullsEyeSlowTests",
+					Name:       "testSlowNetworkRoundTrip()",
+					Result:     xcresult.TestResultPassed,
+					Duration:   1500 * time.Millisecond,
+				},
+			}, results.TestCases())
+			require.Equal(t, []xcresult.Device{{
+				ID:           "D64FA78C-5A25-4BF3-9EE8-855761042DEE",
+				Name:         "iPhone 15",
+				Architecture: "arm64",
+				ModelName:    "iPhone 15",
+				Platform:     "iOS Simulator",
+				OSVersion:    "17.5",
+			}}, results.Devices)
+			require.Equal(t, "UI test bundle", results.TestNodes[1].NodeType)
+
+			factoryMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReadTestResults_Fails(t *testing.T) {
+	factoryMock := new(mocks.Factory)
+	expectXcresulttool(t, factoryMock, []string{"get", "test-results", "tests", "--path", "Test-App.xcresult"}, "Error: file not found", errors.New("exit status 1"))
+	expectXcresulttool(t, factoryMock, []string{"get", "--legacy", "--format", "json", "--path", "Test-App.xcresult"}, "Error: file not found", errors.New("exit status 1"))
+	expectXcresulttool(t, factoryMock, []string{"get", "--format", "json", "--path", "Test-App.xcresult"}, "Error: file not found", errors.New("exit status 1"))
+
+	reader := xcresult.NewReader(factoryMock)
+
+	_, err := reader.ReadTestResults("Test-App.xcresult")
+	require.EqualError(t, err, "failed to read test results of Test-App.xcresult: exit status 1 (legacy API: exit status 1, output: Error: file not found)")
+}
+
+func expectXcresulttool(t *testing.T, factoryMock *mocks.Factory, args []string, out string, err error) {
+	commandMock := new(mocks.Command)
+	commandMock.On("RunAndReturnTrimmedOutput").Return(out, err)
+	factoryMock.On("Create", "xcrun", append([]string{"xcresulttool"}, args...), mock.Anything).Return(commandMock).Once()
+}
+
+func fixture(t *testing.T, name string) string {
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return string(content)
+}
@@ -0,0 +1,225 @@
+package xcresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+const (
+	nodeTypeUnitTests      = "Unit test bundle"
+	nodeTypeUITests        = "UI test bundle"
+	nodeTypeTestSuite      = "Test Suite"
+	nodeTypeTestCase       = "Test Case"
+	nodeTypeRepetition     = "Repetition"
+	nodeTypeFailureMessage = "Failure Message"
+	nodeTypeAttachment     = "Attachment"
+)
+
+// Test results of the test nodes.
+const (
+	TestResultPassed          = "Passed"
+	TestResultFailed          = "Failed"
+	TestResultSkipped         = "Skipped"
+	TestResultExpectedFailure = "Expected Failure"
+)
+
+// TestResults is the test report of a result bundle, as listed by `xcresulttool get test-results tests` (Xcode 16+).
+// The reports of older Xcode versions are converted to the same structure (see legacy.go).
+type TestResults struct {
+	Devices   []Device   `json:"devices"`
+	TestNodes []TestNode `json:"testNodes"`
+}
+
+// Device is a run destination of the tests.
+type Device struct {
+	ID           string `json:"deviceId"`
+	Name         string `json:"deviceName"`
+	Architecture string `json:"architecture"`
+	ModelName    string `json:"modelName"`
+	Platform     string `json:"platform"`
+	OSVersion    string `json:"osVersion"`
+}
+
+type TestNode struct {
+	Identifier        string     `json:"nodeIdentifier"`
+	NodeType          string     `json:"nodeType"`
+	Name              string     `json:"name"`
+	Details           string     `json:"details"`
+	Duration          string     `json:"duration"`
+	DurationInSeconds float64    `json:"durationInSeconds"`
+	Result            string     `json:"result"`
+	Children          []TestNode `json:"children"`
+}
+
+// TestCase is a single test method of the report.
+// Identifier is in the TestTarget/TestClass/testMethod format, used by xcodebuild's -only-testing option.
+// Repetitions are the results of the test iterations, if the test was repeated (see xcodebuild's -test-iterations option).
+// Failures are the failure messages, Attachments are the names of the attachments of the test.
+type TestCase struct {
+	Identifier  string
+	Target      string
+	Class       string
+	Name        string
+	Result      string
+	Duration    time.Duration
+	Repetitions []string
+	Failures    []string
+	Attachments []string
+}
+
+// Summary is the number of tests by their results, and the total duration of the tests.
+type Summary struct {
+	TotalTestCount   int
+	PassedTests      int
+	FailedTests      int
+	SkippedTests     int
+	ExpectedFailures int
+	Duration         time.Duration
+}
+
+type Reader interface {
+	ReadTestResults(xcresultPth string) (*TestResults, error)
+	ExportAttachments(xcresultPth, outputDir string, onlyFailures bool) ([]TestAttachments, error)
+	ReadCoverageReport(xcresultPth string) (*CoverageReport, error)
+	ReadLineCoverage(xcresultPth string) (map[string][]LineCoverage, error)
+}
+
+type reader struct {
+	commandFactory command.Factory
+}
+
+func NewReader(commandFactory command.Factory) Reader {
+	return reader{commandFactory: commandFactory}
+}
+
+// ReadTestResults reads the test report of the result bundle.
+// The test-results API is available from Xcode 16, the report of older Xcode versions is read with the legacy API.
+func (r reader) ReadTestResults(xcresultPth string) (*TestResults, error) {
+	out, err := r.xcresulttool("get", "test-results", "tests", "--path", xcresultPth)
+	if err != nil {
+		results, legacyErr := r.readLegacyTestResults(xcresultPth)
+		if legacyErr != nil {
+			return nil, fmt.Errorf("failed to read test results of %s: %w (legacy API: %s)", xcresultPth, err, legacyErr)
+		}
+		return results, nil
+	}
+
+	return ParseTestResults([]byte(out))
+}
+
+func (r reader) xcresulttool(args ...string) (string, error) {
+	cmd := r.commandFactory.Create("xcrun", append([]string{"xcresulttool"}, args...), nil)
+	return cmd.RunAndReturnTrimmedOutput()
+}
+
+func ParseTestResults(content []byte) (*TestResults, error) {
+	var results TestResults
+	if err := json.Unmarshal(content, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse test results: %w", err)
+	}
+	return &results, nil
+}
+
+// TestCases returns the test methods of the report, in the order of the report.
+func (r TestResults) TestCases() []TestCase {
+	var testCases []TestCase
+	for _, node := range r.TestNodes {
+		testCases = append(testCases, collectTestCases(node, "", "")...)
+	}
+	return testCases
+}
+
+func collectTestCases(node TestNode, target, class string) []TestCase {
+	switch node.NodeType {
+	case nodeTypeUnitTests, nodeTypeUITests:
+		target = node.Name
+	case nodeTypeTestSuite:
+		// Nested suites (Swift Testing) are reported under their outermost suite.
+		if class == "" {
+			class = node.Name
+		}
+	case nodeTypeTestCase:
+		return []TestCase{{
+			Identifier:  target + "/" + class + "/" + strings.TrimSuffix(node.Name, "()"),
+			Target:      target,
+			Class:       class,
+			Name:        node.Name,
+			Result:      node.Result,
+			Duration:    node.duration(),
+			Repetitions: repetitionResults(node),
+			Failures:    childNames(node, nodeTypeFailureMessage),
+			Attachments: childNames(node, nodeTypeAttachment),
+		}}
+	}
+
+	var testCases []TestCase
+	for _, child := range node.Children {
+		testCases = append(testCases, collectTestCases(child, target, class)...)
+	}
+	return testCases
+}
+
+// Summary counts the test cases of the report by their results.
+func (r TestResults) Summary() Summary {
+	var summary Summary
+	for _, testCase := range r.TestCases() {
+		summary.TotalTestCount++
+		summary.Duration += testCase.Duration
+
+		switch testCase.Result {
+		case TestResultPassed:
+			summary.PassedTests++
+		case TestResultFailed:
+			summary.FailedTests++
+		case TestResultSkipped:
+			summary.SkippedTests++
+		case TestResultExpectedFailure:
+			summary.ExpectedFailures++
+		}
+	}
+	return summary
+}
+
+// childNames returns the names of the descendant nodes of the given type, including the ones of the repetitions.
+func childNames(node TestNode, nodeType string) []string {
+	var names []string
+	for _, child := range node.Children {
+		if child.NodeType == nodeType {
+			names = append(names, child.Name)
+			continue
+		}
+		names = append(names, childNames(child, nodeType)...)
+	}
+	return names
+}
+
+// repetitionResults returns the results of the repetitions of the test case, in the order of the report.
+// The repetitions can be nested into device and test plan configuration nodes.
+func repetitionResults(node TestNode) []string {
+	var results []string
+	for _, child := range node.Children {
+		if child.NodeType == nodeTypeRepetition {
+			results = append(results, child.Result)
+			continue
+		}
+		results = append(results, repetitionResults(child)...)
+	}
+	return results
+}
+
+func (n TestNode) duration() time.Duration {
+	if n.DurationInSeconds > 0 {
+		return time.Duration(n.DurationInSeconds * float64(time.Second))
+	}
+
+	// Older xcresulttool versions report the duration in a human readable format, like "1m 2s" or "0.12s".
+	duration, err := time.ParseDuration(strings.ReplaceAll(n.Duration, " ", ""))
+	if err != nil {
+		return 0
+	}
+	return duration
+}
@@ -0,0 +1,92 @@
+package xcresult
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestResults_TestCases(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "xcode16_test_results.json"))
+	require.NoError(t, err)
+
+	results, err := ParseTestResults(content)
+	require.NoError(t, err)
+
+	require.Equal(t, []TestCase{
+		{
+			Identifier: "BullsEyeTests/BullsEyeTests/testScoreIsComputedWhenGuessIsHigherThanTarget",
+			Target:     "BullsEyeTests",
+			Class:      "BullsEyeTests",
+			Name:       "testScoreIsComputedWhenGuessIsHigherThanTarget()",
+			Result:     "Passed",
+			Duration:   4200 * time.Microsecond,
+		},
+		{
+			Identifier: "BullsEyeTests/BullsEyeTests/testPerformanceExample",
+			Target:     "BullsEyeTests",
+			Class:      "BullsEyeTests",
+			Name:       "testPerformanceExample()",
+			Result:     "Passed",
+			Duration:   1200 * time.Millisecond,
+		},
+		{
+			Identifier:  "BullsEyeTests/BullsEyeSlowTests/testSlowNetworkRoundTrip",
+			Target:      "BullsEyeTests",
+			Class:       "BullsEyeSlowTests",
+			Name:        "testSlowNetworkRoundTrip()",
+			Result:      "Failed",
+			Duration:    2 * time.Second,
+			Failures:    []string{`BullsEyeSlowTests.swift:42: XCTAssertEqual failed: ("404") is not equal to ("200")`},
+			Attachments: []string{"Response body"},
+		},
+		{
+			Identifier: "BullsEyeUITests/BullsEyeUITests/testGameStyleSwitch",
+			Target:     "BullsEyeUITests",
+			Class:      "BullsEyeUITests",
+			Name:       "testGameStyleSwitch()",
+			Result:     "Passed",
+			Duration:   64500 * time.Millisecond,
+		},
+	}, results.TestCases())
+}
+
+func TestTestResults_TestCases_Repetitions(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "xcode16_repetitions_test_results.json"))
+	require.NoError(t, err)
+
+	results, err := ParseTestResults(content)
+	require.NoError(t, err)
+
+	testCases := results.TestCases()
+	require.Equal(t, 2, len(testCases))
+	require.Equal(t, "BullsEyeTests/BullsEyeSlowTests/testSlowNetworkRoundTrip", testCases[0].Identifier)
+	require.Equal(t, []string{"Failed", "Passed"}, testCases[0].Repetitions)
+	require.Nil(t, testCases[1].Repetitions)
+}
+
+func TestTestResults_Summary(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "xcode16_test_results.json"))
+	require.NoError(t, err)
+
+	results, err := ParseTestResults(content)
+	require.NoError(t, err)
+
+	require.Equal(t, Summary{
+		TotalTestCount: 4,
+		PassedTests:    3,
+		FailedTests:    1,
+		Duration:       4200*time.Microsecond + 1200*time.Millisecond + 2*time.Second + 64500*time.Millisecond,
+	}, results.Summary())
+	require.Equal(t, []Device{{
+		ID:           "7A4F2E3C-9B1D-4E8A-B6F0-2C5D8E1A3B47",
+		Name:         "iPhone 15 Pro",
+		Architecture: "arm64",
+		ModelName:    "iPhone 15 Pro",
+		Platform:     "iOS Simulator",
+		OSVersion:    "18.0",
+	}}, results.Devices)
+}
@@ -0,0 +1,49 @@
+package xctestrun
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const Ext = ".xctestrun"
+
+// Bundles generated by the build which never contain xctestrun files, no need to walk them.
+var skippedBundleExts = map[string]bool{
+	".app":       true,
+	".appex":     true,
+	".bundle":    true,
+	".dSYM":      true,
+	".framework": true,
+	".xcresult":  true,
+	".xctest":    true,
+}
+
+// Find returns the xctestrun files in the given directory and its subdirectories.
+func Find(dir string) ([]string, error) {
+	var pths []string
+	err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if pth != dir && skippedBundleExts[filepath.Ext(pth)] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(pth) == Ext {
+			pths = append(pths, pth)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for xctestrun files in %s: %w", dir, err)
+	}
+
+	sort.Strings(pths)
+	return pths, nil
+}
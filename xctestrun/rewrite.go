@@ -0,0 +1,130 @@
+package xctestrun
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// TestEnvironment holds the values injected into each test target of an xctestrun.
+type TestEnvironment struct {
+	EnvironmentVariables map[string]string
+	LaunchArguments      []string
+}
+
+// WriteWithTestEnvironment writes a copy of the xctestrun to dst with the given environment variables
+// merged into each test target's EnvironmentVariables and TestingEnvironmentVariables and the launch arguments
+// appended to its CommandLineArguments. The original file is left untouched.
+//
+// The __TESTROOT__ placeholder is resolved to the test root of the original file (see TestRoot),
+// so the copy can be written outside of the build products directory.
+func (t TestRun) WriteWithTestEnvironment(dst string, environment TestEnvironment) error {
+	content, err := os.ReadFile(t.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read xctestrun file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	format, err := plist.Unmarshal(content, &raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse xctestrun file (%s): %w", t.Path, err)
+	}
+
+	for _, target := range rawTestTargets(raw, t.FormatVersion) {
+		mergeStringDict(target, "EnvironmentVariables", environment.EnvironmentVariables)
+		mergeStringDict(target, "TestingEnvironmentVariables", environment.EnvironmentVariables)
+		appendStringArray(target, "CommandLineArguments", environment.LaunchArguments)
+	}
+
+	resolved := resolvePlaceholder(raw, testRootPlaceholder, t.TestRoot())
+
+	content, err = plist.MarshalIndent(resolved, format, "\t")
+	if err != nil {
+		return fmt.Errorf("failed to encode xctestrun file: %w", err)
+	}
+
+	if err := os.WriteFile(dst, content, 0644); err != nil {
+		return fmt.Errorf("failed to write xctestrun file: %w", err)
+	}
+
+	return nil
+}
+
+func rawTestTargets(raw map[string]interface{}, formatVersion int) []map[string]interface{} {
+	var targets []map[string]interface{}
+
+	if formatVersion == FormatVersion1 {
+		for key, value := range raw {
+			if key == metadataKey {
+				continue
+			}
+			if target, ok := value.(map[string]interface{}); ok {
+				targets = append(targets, target)
+			}
+		}
+		return targets
+	}
+
+	configurations, _ := raw["TestConfigurations"].([]interface{})
+	for _, configuration := range configurations {
+		configurationDict, ok := configuration.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		configurationTargets, _ := configurationDict["TestTargets"].([]interface{})
+		for _, target := range configurationTargets {
+			if targetDict, ok := target.(map[string]interface{}); ok {
+				targets = append(targets, targetDict)
+			}
+		}
+	}
+	return targets
+}
+
+func mergeStringDict(target map[string]interface{}, key string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	dict, ok := target[key].(map[string]interface{})
+	if !ok {
+		dict = map[string]interface{}{}
+	}
+	for k, v := range values {
+		dict[k] = v
+	}
+	target[key] = dict
+}
+
+func appendStringArray(target map[string]interface{}, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	array, _ := target[key].([]interface{})
+	for _, value := range values {
+		array = append(array, value)
+	}
+	target[key] = array
+}
+
+func resolvePlaceholder(value interface{}, placeholder, replacement string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return strings.ReplaceAll(v, placeholder, replacement)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = resolvePlaceholder(item, placeholder, replacement)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = resolvePlaceholder(item, placeholder, replacement)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,74 @@
+package xctestrun
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteWithTestEnvironment(t *testing.T) {
+	fixtures := []string{
+		"xcode13_format_v1.xctestrun",
+		"xcode15_format_v2_multiple_configurations.xctestrun",
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			original := filepath.Join("testdata", fixture)
+			originalContent, err := os.ReadFile(original)
+			require.NoError(t, err)
+
+			testRun, err := Parse(original)
+			require.NoError(t, err)
+
+			dst := filepath.Join(t.TempDir(), fixture)
+			err = testRun.WriteWithTestEnvironment(dst, TestEnvironment{
+				EnvironmentVariables: map[string]string{"API_BASE_URL": "https://staging.example.com", "OS_ACTIVITY_DT_MODE": "NO"},
+				LaunchArguments:      []string{"-FeatureFlags", "all"},
+			})
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(original)
+			require.NoError(t, err)
+			require.Equal(t, originalContent, content, "the original file must be left untouched")
+
+			rewritten, err := Parse(dst)
+			require.NoError(t, err)
+			require.Equal(t, testRun.TestTargetNames(), rewritten.TestTargetNames())
+			require.Equal(t, testRun.ConfigurationNames(), rewritten.ConfigurationNames())
+
+			for i, configuration := range rewritten.TestConfigurations {
+				for j, target := range configuration.TestTargets {
+					originalTarget := testRun.TestConfigurations[i].TestTargets[j]
+
+					require.Equal(t, "https://staging.example.com", target.EnvironmentVariables["API_BASE_URL"])
+					require.Equal(t, "NO", target.EnvironmentVariables["OS_ACTIVITY_DT_MODE"])
+					require.Equal(t, "https://staging.example.com", target.TestingEnvironmentVariables["API_BASE_URL"])
+					require.Equal(t, append(originalTarget.CommandLineArguments, "-FeatureFlags", "all"), target.CommandLineArguments)
+					require.Equal(t, strings.Replace(originalTarget.TestHostPath, "__TESTROOT__", testRun.TestRoot(), 1), target.TestHostPath)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteWithTestEnvironment_TestProducts(t *testing.T) {
+	testRuns, err := ParseTestProducts(filepath.Join("testdata", "BullsEye.xctestproducts"))
+	require.NoError(t, err)
+	testRun := testRuns[0]
+
+	dst := filepath.Join(t.TempDir(), filepath.Base(testRun.Path))
+	err = testRun.WriteWithTestEnvironment(dst, TestEnvironment{EnvironmentVariables: map[string]string{"API_BASE_URL": "https://staging.example.com"}})
+	require.NoError(t, err)
+
+	rewritten, err := Parse(dst)
+	require.NoError(t, err)
+
+	for _, target := range rewritten.TestTargets() {
+		require.Equal(t, "https://staging.example.com", target.EnvironmentVariables["API_BASE_URL"])
+		require.True(t, strings.HasPrefix(target.TestHostPath, filepath.Join(testRun.TestProductsPath, "Binaries", "0")), target.TestHostPath)
+		require.DirExists(t, target.TestHostPath, "the build products of the bundle are referenced")
+	}
+}
@@ -0,0 +1,66 @@
+package xctestrun
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"howett.net/plist"
+)
+
+// TestProductsExt is the extension of the test products bundles exported by Xcode 15+ (xcodebuild -testProductsPath).
+const TestProductsExt = ".xctestproducts"
+
+// testProductsBinariesDir is the directory of the build products in a test products bundle.
+const testProductsBinariesDir = "Binaries"
+
+// ParseTestProducts validates the structure of a test products bundle and parses the xctestrun files it contains.
+// The returned test runs refer to the bundle by their TestProductsPath.
+func ParseTestProducts(pth string) ([]*TestRun, error) {
+	info, err := os.Stat(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test products bundle: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("invalid test products bundle (%s): not a directory", pth)
+	}
+
+	infoPlistPth := filepath.Join(pth, "Info.plist")
+	content, err := os.ReadFile(infoPlistPth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("invalid test products bundle (%s): Info.plist not found", pth)
+		}
+		return nil, err
+	}
+
+	var infoPlist map[string]interface{}
+	if _, err := plist.Unmarshal(content, &infoPlist); err != nil {
+		return nil, fmt.Errorf("invalid test products bundle (%s): failed to parse Info.plist: %w", pth, err)
+	}
+
+	xctestrunPths, err := Find(pth)
+	if err != nil {
+		return nil, err
+	}
+	if len(xctestrunPths) == 0 {
+		return nil, fmt.Errorf("invalid test products bundle (%s): no xctestrun file found", pth)
+	}
+
+	absPth, err := filepath.Abs(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	var testRuns []*TestRun
+	for _, xctestrunPth := range xctestrunPths {
+		testRun, err := Parse(xctestrunPth)
+		if err != nil {
+			return nil, err
+		}
+		testRun.TestProductsPath = absPth
+		testRuns = append(testRuns, testRun)
+	}
+
+	return testRuns, nil
+}
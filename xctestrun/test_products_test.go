@@ -0,0 +1,74 @@
+package xctestrun
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTestProducts(t *testing.T) {
+	pth := filepath.Join("testdata", "BullsEye.xctestproducts")
+
+	testRuns, err := ParseTestProducts(pth)
+	require.NoError(t, err)
+	require.Len(t, testRuns, 1)
+
+	absPth, err := filepath.Abs(pth)
+	require.NoError(t, err)
+	require.Equal(t, absPth, testRuns[0].TestProductsPath)
+	require.Equal(t, filepath.Join(absPth, "Tests", "0", "BullsEye_FullTests_iphonesimulator18.0-arm64.xctestrun"), testRuns[0].Path)
+	require.Equal(t, &TestPlan{Name: "FullTests", IsDefault: true}, testRuns[0].TestPlan)
+	require.Equal(t, []string{"BullsEyeTests", "BullsEyeSlowTests", "BullsEyeUITests"}, testRuns[0].TestTargetNames())
+	require.Equal(t, filepath.Join(absPth, "Binaries", "0"), testRuns[0].TestRoot())
+}
+
+func TestParseTestProducts_InvalidBundle(t *testing.T) {
+	xctestrunContent, err := os.ReadFile(filepath.Join("testdata", "xcode16_format_v2.xctestrun"))
+	require.NoError(t, err)
+	infoPlistContent, err := os.ReadFile(filepath.Join("testdata", "BullsEye.xctestproducts", "Info.plist"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		wantErr string
+	}{
+		{
+			name:    "Missing Info.plist",
+			files:   map[string][]byte{"Tests/0/BullsEye.xctestrun": xctestrunContent},
+			wantErr: "Info.plist not found",
+		},
+		{
+			name:    "Invalid Info.plist",
+			files:   map[string][]byte{"Info.plist": []byte("<plist"), "Tests/0/BullsEye.xctestrun": xctestrunContent},
+			wantErr: "failed to parse Info.plist",
+		},
+		{
+			name:    "Missing xctestrun",
+			files:   map[string][]byte{"Info.plist": infoPlistContent},
+			wantErr: "no xctestrun file found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "BullsEye.xctestproducts")
+			for name, content := range tt.files {
+				require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(pth, name)), 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(pth, name), content, 0644))
+			}
+
+			_, err := ParseTestProducts(pth)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseTestProducts_NotADirectory(t *testing.T) {
+	pth := filepath.Join("testdata", "xcode16_format_v2.xctestrun")
+
+	_, err := ParseTestProducts(pth)
+	require.EqualError(t, err, "invalid test products bundle ("+pth+"): not a directory")
+}
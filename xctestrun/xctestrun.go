@@ -0,0 +1,210 @@
+package xctestrun
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"howett.net/plist"
+)
+
+const (
+	FormatVersion1 = 1
+	FormatVersion2 = 2
+
+	metadataKey         = "__xctestrun_metadata__"
+	testRootPlaceholder = "__TESTROOT__"
+	testHostPlaceholder = "__TESTHOST__"
+)
+
+// TestRun is the parsed content of an xctestrun file.
+// Format version 1 files have no test plan and no named configurations,
+// their test targets are stored in a single configuration with an empty name.
+// TestProductsPath is set if the xctestrun is part of a test products bundle.
+type TestRun struct {
+	Path               string
+	TestProductsPath   string
+	FormatVersion      int
+	TestPlan           *TestPlan
+	TestConfigurations []TestConfiguration
+}
+
+type TestPlan struct {
+	Name      string `plist:"Name"`
+	IsDefault bool   `plist:"IsDefault"`
+}
+
+type TestConfiguration struct {
+	Name        string       `plist:"Name"`
+	TestTargets []TestTarget `plist:"TestTargets"`
+}
+
+type TestTarget struct {
+	BlueprintName               string            `plist:"BlueprintName"`
+	TestBundlePath              string            `plist:"TestBundlePath"`
+	TestHostPath                string            `plist:"TestHostPath"`
+	TestHostBundleIdentifier    string            `plist:"TestHostBundleIdentifier"`
+	UITargetAppPath             string            `plist:"UITargetAppPath"`
+	IsUITestBundle              bool              `plist:"IsUITestBundle"`
+	IsAppHostedTestBundle       bool              `plist:"IsAppHostedTestBundle"`
+	EnvironmentVariables        map[string]string `plist:"EnvironmentVariables"`
+	TestingEnvironmentVariables map[string]string `plist:"TestingEnvironmentVariables"`
+	CommandLineArguments        []string          `plist:"CommandLineArguments"`
+	DependentProductPaths       []string          `plist:"DependentProductPaths"`
+	OnlyTestIdentifiers         []string          `plist:"OnlyTestIdentifiers"`
+	SkipTestIdentifiers         []string          `plist:"SkipTestIdentifiers"`
+}
+
+type metadata struct {
+	Metadata struct {
+		FormatVersion int `plist:"FormatVersion"`
+	} `plist:"__xctestrun_metadata__"`
+}
+
+type formatV2 struct {
+	TestPlan           *TestPlan           `plist:"TestPlan"`
+	TestConfigurations []TestConfiguration `plist:"TestConfigurations"`
+}
+
+// Parse reads and decodes the xctestrun file at the given path.
+func Parse(pth string) (*TestRun, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read xctestrun file: %w", err)
+	}
+
+	testRun, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse xctestrun file (%s): %w", pth, err)
+	}
+
+	absPth, err := filepath.Abs(pth)
+	if err != nil {
+		return nil, err
+	}
+	testRun.Path = absPth
+
+	return testRun, nil
+}
+
+func parse(content []byte) (*TestRun, error) {
+	var meta metadata
+	if _, err := plist.Unmarshal(content, &meta); err != nil {
+		return nil, err
+	}
+
+	switch meta.Metadata.FormatVersion {
+	case FormatVersion1:
+		return parseFormatV1(content)
+	case FormatVersion2:
+		return parseFormatV2(content)
+	default:
+		return nil, fmt.Errorf("unsupported format version: %d", meta.Metadata.FormatVersion)
+	}
+}
+
+func parseFormatV1(content []byte) (*TestRun, error) {
+	var targetsByName map[string]TestTarget
+	if _, err := plist.Unmarshal(content, &targetsByName); err != nil {
+		return nil, err
+	}
+	delete(targetsByName, metadataKey)
+
+	var names []string
+	for name := range targetsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []TestTarget
+	for _, name := range names {
+		target := targetsByName[name]
+		if target.BlueprintName == "" {
+			target.BlueprintName = name
+		}
+		targets = append(targets, target)
+	}
+
+	return &TestRun{
+		FormatVersion:      FormatVersion1,
+		TestConfigurations: []TestConfiguration{{TestTargets: targets}},
+	}, nil
+}
+
+func parseFormatV2(content []byte) (*TestRun, error) {
+	var v2 formatV2
+	if _, err := plist.Unmarshal(content, &v2); err != nil {
+		return nil, err
+	}
+
+	return &TestRun{
+		FormatVersion:      FormatVersion2,
+		TestPlan:           v2.TestPlan,
+		TestConfigurations: v2.TestConfigurations,
+	}, nil
+}
+
+// TestRoot returns the directory the __TESTROOT__ placeholder refers to.
+// In a test products bundle the xctestrun files are in Tests/N, while the build products they refer to are in Binaries/N.
+func (t TestRun) TestRoot() string {
+	if t.TestProductsPath != "" {
+		return filepath.Join(t.TestProductsPath, testProductsBinariesDir, filepath.Base(filepath.Dir(t.Path)))
+	}
+	return filepath.Dir(t.Path)
+}
+
+// ResolvePath resolves the __TESTROOT__ and __TESTHOST__ placeholders of a path referenced by the given test target.
+// Returns false if the path refers to a location outside of the test root (for example __PLATFORMS__).
+func (t TestRun) ResolvePath(target TestTarget, pth string) (string, bool) {
+	switch {
+	case strings.HasPrefix(pth, testRootPlaceholder):
+		return filepath.Join(t.TestRoot(), strings.TrimPrefix(pth, testRootPlaceholder)), true
+	case strings.HasPrefix(pth, testHostPlaceholder):
+		testHostPath, ok := t.ResolvePath(target, target.TestHostPath)
+		if !ok {
+			return "", false
+		}
+		return filepath.Join(testHostPath, strings.TrimPrefix(pth, testHostPlaceholder)), true
+	default:
+		return "", false
+	}
+}
+
+// TestTargets returns the test targets of all configurations,
+// a target present in multiple configurations is listed only once.
+func (t TestRun) TestTargets() []TestTarget {
+	var targets []TestTarget
+	seen := map[string]bool{}
+	for _, configuration := range t.TestConfigurations {
+		for _, target := range configuration.TestTargets {
+			if seen[target.BlueprintName] {
+				continue
+			}
+			seen[target.BlueprintName] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// TestTargetNames returns the names of the test targets.
+func (t TestRun) TestTargetNames() []string {
+	var names []string
+	for _, target := range t.TestTargets() {
+		names = append(names, target.BlueprintName)
+	}
+	return names
+}
+
+// ConfigurationNames returns the names of the test plan configurations.
+func (t TestRun) ConfigurationNames() []string {
+	var names []string
+	for _, configuration := range t.TestConfigurations {
+		if configuration.Name != "" {
+			names = append(names, configuration.Name)
+		}
+	}
+	return names
+}
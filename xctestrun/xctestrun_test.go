@@ -0,0 +1,124 @@
+package xctestrun
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name               string
+		fixture            string
+		wantFormatVersion  int
+		wantTestPlan       *TestPlan
+		wantConfigurations []string
+		wantTargets        []string
+	}{
+		{
+			name:              "Xcode 13, format version 1",
+			fixture:           "xcode13_format_v1.xctestrun",
+			wantFormatVersion: FormatVersion1,
+			wantTargets:       []string{"BullsEyeTests", "BullsEyeUITests"},
+		},
+		{
+			name:               "Xcode 14, format version 2",
+			fixture:            "xcode14_format_v2.xctestrun",
+			wantFormatVersion:  FormatVersion2,
+			wantTestPlan:       &TestPlan{Name: "FullTests", IsDefault: true},
+			wantConfigurations: []string{"Test Scheme Action"},
+			wantTargets:        []string{"BullsEyeTests", "BullsEyeUITests"},
+		},
+		{
+			name:               "Xcode 15, format version 2 with multiple configurations",
+			fixture:            "xcode15_format_v2_multiple_configurations.xctestrun",
+			wantFormatVersion:  FormatVersion2,
+			wantTestPlan:       &TestPlan{Name: "Localization", IsDefault: false},
+			wantConfigurations: []string{"English", "German"},
+			wantTargets:        []string{"BullsEyeTests", "BullsEyeUITests"},
+		},
+		{
+			name:               "Xcode 16, format version 2",
+			fixture:            "xcode16_format_v2.xctestrun",
+			wantFormatVersion:  FormatVersion2,
+			wantTestPlan:       &TestPlan{Name: "FullTests", IsDefault: true},
+			wantConfigurations: []string{"Configuration 1"},
+			wantTargets:        []string{"BullsEyeTests", "BullsEyeSlowTests", "BullsEyeUITests"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testRun, err := Parse(filepath.Join("testdata", tt.fixture))
+			require.NoError(t, err)
+
+			require.Equal(t, tt.wantFormatVersion, testRun.FormatVersion)
+			require.Equal(t, tt.wantTestPlan, testRun.TestPlan)
+			require.Equal(t, tt.wantConfigurations, testRun.ConfigurationNames())
+			require.Equal(t, tt.wantTargets, testRun.TestTargetNames())
+			require.True(t, filepath.IsAbs(testRun.Path))
+		})
+	}
+}
+
+func TestParse_TestTargetDetails(t *testing.T) {
+	testRun, err := Parse(filepath.Join("testdata", "xcode13_format_v1.xctestrun"))
+	require.NoError(t, err)
+
+	targets := testRun.TestTargets()
+	require.Len(t, targets, 2)
+
+	unitTests := targets[0]
+	require.False(t, unitTests.IsUITestBundle)
+	require.True(t, unitTests.IsAppHostedTestBundle)
+	require.Equal(t, "__TESTROOT__/Debug-iphonesimulator/BullsEye.app", unitTests.TestHostPath)
+	require.Equal(t, "__TESTHOST__/PlugIns/BullsEyeTests.xctest", unitTests.TestBundlePath)
+	require.Equal(t, "YES", unitTests.EnvironmentVariables["OS_ACTIVITY_DT_MODE"])
+	require.Equal(t, "unused", unitTests.TestingEnvironmentVariables["XCInjectBundleInto"])
+
+	uiTests := targets[1]
+	require.True(t, uiTests.IsUITestBundle)
+	require.Equal(t, "__TESTROOT__/Debug-iphonesimulator/BullsEyeUITests-Runner.app", uiTests.TestHostPath)
+	require.Equal(t, "__TESTROOT__/Debug-iphonesimulator/BullsEye.app", uiTests.UITargetAppPath)
+	require.Equal(t, []string{"-UITesting"}, uiTests.CommandLineArguments)
+}
+
+func TestParse_TestIdentifiers(t *testing.T) {
+	testRun, err := Parse(filepath.Join("testdata", "xcode16_format_v2.xctestrun"))
+	require.NoError(t, err)
+
+	targets := testRun.TestTargets()
+	require.Len(t, targets, 3)
+	require.Equal(t, []string{"BullsEyeTests/testPerformanceExample"}, targets[0].SkipTestIdentifiers)
+	require.Equal(t, []string{"BullsEyeUITests/testGameStyleSwitch", "BullsEyeUITests/testLaunchPerformance"}, targets[2].OnlyTestIdentifiers)
+}
+
+func TestParse_UnsupportedFormatVersion(t *testing.T) {
+	_, err := parse([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>__xctestrun_metadata__</key>
+	<dict>
+		<key>FormatVersion</key>
+		<integer>3</integer>
+	</dict>
+</dict>
+</plist>`))
+	require.EqualError(t, err, "unsupported format version: 3")
+}
+
+func TestResolvePath(t *testing.T) {
+	testRun := TestRun{Path: "/Build/Products/BullsEye.xctestrun"}
+	target := TestTarget{TestHostPath: "__TESTROOT__/Debug-iphonesimulator/BullsEye.app"}
+
+	pth, ok := testRun.ResolvePath(target, target.TestHostPath)
+	require.True(t, ok)
+	require.Equal(t, "/Build/Products/Debug-iphonesimulator/BullsEye.app", pth)
+
+	pth, ok = testRun.ResolvePath(target, "__TESTHOST__/PlugIns/BullsEyeTests.xctest")
+	require.True(t, ok)
+	require.Equal(t, "/Build/Products/Debug-iphonesimulator/BullsEye.app/PlugIns/BullsEyeTests.xctest", pth)
+
+	_, ok = testRun.ResolvePath(target, "__PLATFORMS__/iPhoneSimulator.platform/Developer/usr/lib/libXCTestBundleInject.dylib")
+	require.False(t, ok)
+}